@@ -1,10 +1,22 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"coral.daniel-guo.com/internal/config"
+	"coral.daniel-guo.com/internal/email"
+	"coral.daniel-guo.com/internal/locations"
 	"coral.daniel-guo.com/internal/logger"
+	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/schema"
 	"coral.daniel-guo.com/internal/service"
 	"github.com/spf13/cobra"
 )
@@ -14,7 +26,7 @@ var sendEmailCmd = &cobra.Command{
 	Use:   "send-email",
 	Short: "Send club transfer emails",
 	Long: `Send club transfer notification emails to clubs.
-This command processes club transfer data from a CSV file and sends 
+This command processes club transfer data from a CSV or XLSX file and sends 
 personalized emails to each club with their relevant transfer information.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Set logging level based on verbose flag
@@ -28,19 +40,56 @@ personalized emails to each club with their relevant transfer information.`,
 
 		// Load application configuration
 		appConfig := config.NewAppConfig(envFlag, testEmailFlag, senderFlag)
+		applyTransportFlags(appConfig)
+		err := applySchema(appConfig)
+		if err == nil {
+			err = applyColumnAliases(appConfig)
+		}
+		if err == nil {
+			err = applyLocationAliases(appConfig)
+		}
+		if err != nil {
+			logger.Error("Invalid arguments: %v", err)
+			os.Exit(1)
+		}
+		appConfig.Templates.Dir = templateDirFlag
+		appConfig.JournalDir = journalDirFlag
+		appConfig.ReportPath = reportFlag
+		appConfig.MaxRate = maxRateFlag
+		appConfig.Email.ReplyTo = replyToFlag
+		appConfig.Email.Bcc = bccFlag
+		appConfig.AttachmentFormat = attachmentFormatFlag
+		appConfig.SkipInvalidRows = skipInvalidRowsFlag
+		appConfig.RejectsPath = rejectsFlag
+		appConfig.MaxInvalidPercent = maxInvalidPercentFlag
+		appConfig.Grouping.MaxInMemory = spillThresholdFlag
+		appConfig.Grouping.Dir = spillDirFlag
+		appConfig.OnDuplicate = onDuplicateFlag
+		appConfig.HistoryPath = historyFlag
+		appConfig.DryRun = dryRunFlag
+		appConfig.DryRunDir = dryRunDirFlag
+		if dryRunFlag {
+			logger.Info("Dry run enabled, emails will be written to %s instead of being sent", dryRunDirFlag)
+		}
 
 		// Create transfer service
 		transferService := service.NewService(appConfig)
 
 		// Create transfer request
-		req := service.TransferRequest{
-			TransferType: typeFlag,
-			FileName:     inputFlag,
+		req, err := newTransferRequest()
+		if err != nil {
+			logger.Error("Invalid arguments: %v", err)
+			os.Exit(1)
 		}
 
+		// Stop handing out clubs on SIGINT/SIGTERM; a second signal terminates immediately
+		ctx, stop := notifyContext(cmd.Context())
+		defer stop()
+
 		// Process the request
-		if err := transferService.Process(req); err != nil {
+		if err := transferService.Process(ctx, req); err != nil {
 			logger.Error("Failed to process club transfers: %v", err)
+			stop()
 			os.Exit(1)
 		}
 	},
@@ -53,13 +102,185 @@ var (
 	envFlag       string
 	testEmailFlag string
 	verboseFlag   bool
+
+	sheetFlag       string
+	schemaFlag      string
+	columnAliasFlag []string
+
+	locationAliasesFlag string
+	confirmAliasesFlag  bool
+
+	skipInvalidRowsFlag   bool
+	rejectsFlag           string
+	maxInvalidPercentFlag float64
+
+	spillThresholdFlag int
+	spillDirFlag       string
+
+	onDuplicateFlag string
+	historyFlag     string
+
+	transportFlag    string
+	smtpHostFlag     string
+	smtpPortFlag     int
+	smtpUsernameFlag string
+	smtpSecurityFlag string
+	smtpAuthFlag     string
+	mailDirFlag      string
+	maildirFlag      bool
+
+	dryRunFlag    bool
+	dryRunDirFlag string
+
+	templateDirFlag string
+
+	periodFlag string
+	asOfFlag   string
+
+	resumeFlag     bool
+	journalDirFlag string
+
+	reportFlag string
+
+	maxRateFlag float64
+
+	replyToFlag []string
+	bccFlag     []string
+
+	attachmentFormatFlag string
 )
 
+// asOfLayout is the date format accepted by --as-of
+const asOfLayout = "2006-01-02"
+
+// newTransferRequest builds the transfer request from the command flags
+func newTransferRequest() (service.TransferRequest, error) {
+	req := service.TransferRequest{
+		TransferType: typeFlag,
+		FileName:     inputFlag,
+		Sheet:        sheetFlag,
+		Resume:       resumeFlag,
+	}
+
+	if periodFlag != "" {
+		period, err := model.ParsePeriod(periodFlag)
+		if err != nil {
+			return req, err
+		}
+		req.Period = period
+	}
+
+	if asOfFlag != "" {
+		asOf, err := time.Parse(asOfLayout, asOfFlag)
+		if err != nil {
+			return req, fmt.Errorf("invalid as-of date %q: expected YYYY-MM-DD", asOfFlag)
+		}
+		req.AsOf = asOf
+	}
+
+	return req, nil
+}
+
+// smtpPasswordEnv is the environment variable holding the SMTP password
+const smtpPasswordEnv = "SMTP_PASSWORD"
+
+// applySchema loads the --schema file, if any, into the input and output columns
+func applySchema(appConfig *config.AppConfig) error {
+	if schemaFlag == "" {
+		return nil
+	}
+	s, err := schema.Load(schemaFlag)
+	if err != nil {
+		return err
+	}
+	appConfig.ApplySchema(s)
+	return nil
+}
+
+// applyColumnAliases adds the --column-alias flags, given as "Column=Alias",
+// to the input header aliases
+func applyColumnAliases(appConfig *config.AppConfig) error {
+	for _, flag := range columnAliasFlag {
+		column, alias, ok := strings.Cut(flag, "=")
+		if !ok || strings.TrimSpace(alias) == "" {
+			return fmt.Errorf("invalid column alias %q: expected Column=Alias", flag)
+		}
+		if err := appConfig.Input.AddAlias(strings.TrimSpace(column), strings.TrimSpace(alias)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyLocationAliases loads the --location-aliases file, if any. With
+// --confirm-aliases confirmed aliases are saved to the file, which is created
+// if it does not exist yet.
+func applyLocationAliases(appConfig *config.AppConfig) error {
+	appConfig.ConfirmAliases = confirmAliasesFlag
+	appConfig.LocationAliasesPath = locationAliasesFlag
+	if locationAliasesFlag == "" {
+		return nil
+	}
+	if _, err := os.Stat(locationAliasesFlag); confirmAliasesFlag && errors.Is(err, fs.ErrNotExist) {
+		appConfig.LocationAliases = locations.Aliases{}
+		return nil
+	}
+	aliases, err := locations.LoadAliases(locationAliasesFlag)
+	if err != nil {
+		return err
+	}
+	appConfig.LocationAliases = aliases
+	return nil
+}
+
+// applyTransportFlags applies the mail transport flags to the application configuration
+func applyTransportFlags(appConfig *config.AppConfig) {
+	if transportFlag != "" {
+		appConfig.Email.Transport = transportFlag
+	}
+	appConfig.Email.SMTP = email.SMTPConfig{
+		Host:     smtpHostFlag,
+		Port:     smtpPortFlag,
+		Username: smtpUsernameFlag,
+		Password: os.Getenv(smtpPasswordEnv),
+		Security: smtpSecurityFlag,
+		Auth:     smtpAuthFlag,
+	}
+	appConfig.Email.File = email.FileConfig{
+		Dir:     mailDirFlag,
+		Maildir: maildirFlag,
+	}
+}
+
 func init() {
 	sendEmailCmd.Flags().
 		StringVarP(&typeFlag, "type", "t", "", "Club transfer type: PIF (Paid in Full) or DD (Direct Debit)")
 
-	sendEmailCmd.Flags().StringVarP(&inputFlag, "input", "i", "", "CSV input file with transfer data")
+	sendEmailCmd.Flags().StringVarP(&inputFlag, "input", "i", "", "CSV or XLSX input file with transfer data")
+	sendEmailCmd.Flags().
+		StringVarP(&sheetFlag, "sheet", "", "", "Sheet of an XLSX input file, by name or 1-based position (default: first)")
+	sendEmailCmd.Flags().
+		StringVarP(&schemaFlag, "schema", "", "", "YAML or JSON file declaring the input and output columns")
+	sendEmailCmd.Flags().
+		StringArrayVarP(&columnAliasFlag, "column-alias", "", nil, `Extra input header name, e.g. "Home Club=Branch"`)
+	sendEmailCmd.Flags().
+		StringVarP(&locationAliasesFlag, "location-aliases", "", "", "YAML or JSON file mapping club to location names")
+	sendEmailCmd.Flags().
+		BoolVarP(&confirmAliasesFlag, "confirm-aliases", "", false, "Ask which suggested location an unknown club is")
+	sendEmailCmd.Flags().
+		BoolVarP(&skipInvalidRowsFlag, "skip-invalid-rows", "", false, "Skip invalid input rows instead of aborting")
+	sendEmailCmd.Flags().
+		StringVarP(&rejectsFlag, "rejects", "", "rejects.csv", "File the skipped invalid rows are written to")
+	sendEmailCmd.Flags().
+		Float64VarP(&maxInvalidPercentFlag, "max-invalid-percent", "", 10, "Abort if over this percent of rows is invalid")
+	sendEmailCmd.Flags().
+		IntVarP(&spillThresholdFlag, "spill-threshold", "", 100000, "Transfers in memory before spilling to disk")
+	sendEmailCmd.Flags().
+		StringVarP(&spillDirFlag, "spill-dir", "", "", "Directory for spilled transfer files (default: system temp dir)")
+	sendEmailCmd.Flags().
+		StringVarP(&onDuplicateFlag, "on-duplicate", "", "warn", "Duplicate or already notified rows: skip, warn or fail")
+	sendEmailCmd.Flags().
+		StringVarP(&historyFlag, "history", "", ".journal/history.jsonl", "File recording transfers already notified")
 
 	sendEmailCmd.Flags().StringVarP(&senderFlag, "sender", "s", "", "Sender email address")
 	sendEmailCmd.Flags().StringVarP(&envFlag, "env", "e", "", "Environment (dev, staging, prod)")
@@ -68,4 +289,74 @@ func init() {
 		StringVarP(&testEmailFlag, "test-email", "", "", "Test email address (if set, all emails go here instead of to clubs)")
 
 	sendEmailCmd.Flags().BoolVarP(&verboseFlag, "verbose", "v", false, "Enable verbose debugging output")
+
+	sendEmailCmd.Flags().
+		StringVarP(&transportFlag, "transport", "", "", "Mail transport: ses (default), smtp or file")
+	sendEmailCmd.Flags().StringVarP(&smtpHostFlag, "smtp-host", "", "", "SMTP server host (smtp transport)")
+	sendEmailCmd.Flags().
+		IntVarP(&smtpPortFlag, "smtp-port", "", 0, "SMTP server port (default: 587, 465 for tls, 25 for none)")
+	sendEmailCmd.Flags().
+		StringVarP(&smtpSecurityFlag, "smtp-security", "", "", "SMTP security: starttls, tls or none (default: auto)")
+	sendEmailCmd.Flags().
+		StringVarP(&smtpUsernameFlag, "smtp-username", "", "", "SMTP username, password is read from "+smtpPasswordEnv)
+	sendEmailCmd.Flags().
+		StringVarP(&smtpAuthFlag, "smtp-auth", "", "", "SMTP auth mechanism: plain or login (default: plain if offered)")
+	sendEmailCmd.Flags().StringVarP(&mailDirFlag, "mail-dir", "", "", "Directory to write messages to (file transport)")
+	sendEmailCmd.Flags().
+		BoolVarP(&maildirFlag, "maildir", "", false, "Write messages in maildir layout (file transport)")
+
+	sendEmailCmd.Flags().
+		BoolVarP(&dryRunFlag, "dry-run", "", false, "Write every email as an .eml file with a summary instead of sending")
+	sendEmailCmd.Flags().
+		StringVarP(&dryRunDirFlag, "dry-run-dir", "", "dry-run", "Directory for dry run .eml files and summary")
+
+	sendEmailCmd.Flags().
+		StringVarP(&templateDirFlag, "template-dir", "", "", "Directory with subject/body templates overriding the defaults")
+
+	sendEmailCmd.Flags().
+		StringVarP(&periodFlag, "period", "", "", "Reporting period, e.g. 2025-05 or 2025-Q2 (default: derived from --as-of)")
+	sendEmailCmd.Flags().
+		StringVarP(&asOfFlag, "as-of", "", "", "Date the run is treated as happening on, YYYY-MM-DD (default: today)")
+
+	sendEmailCmd.Flags().
+		BoolVarP(&resumeFlag, "resume", "", false, "Only send to clubs not yet delivered by a previous run of the same file")
+	sendEmailCmd.Flags().
+		StringVarP(&journalDirFlag, "journal-dir", "", ".journal", "Directory for run journals recording club deliveries")
+
+	sendEmailCmd.Flags().
+		StringVarP(&reportFlag, "report", "", "", "Write a per-club run report to this path (CSV for .csv, JSON otherwise)")
+
+	sendEmailCmd.Flags().
+		Float64VarP(&maxRateFlag, "max-rate", "", 0, "Emails/s across workers (default: SES quota, none for SMTP/file)")
+
+	sendEmailCmd.Flags().
+		StringSliceVarP(&replyToFlag, "reply-to", "", nil, "Reply-To address for every email (comma-separated)")
+	sendEmailCmd.Flags().
+		StringSliceVarP(&bccFlag, "bcc", "", nil, "Blind copy every email to these addresses, e.g. head office")
+
+	sendEmailCmd.Flags().
+		StringVarP(&attachmentFormatFlag, "attachment-format", "", "csv", "Attachment format: csv or xlsx")
+}
+
+// notifyContext returns a context that is cancelled on the first SIGINT or
+// SIGTERM. Signal handling is then released so a second signal terminates
+// the process with the default behaviour.
+func notifyContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			logger.Warn("Received %s, finishing in-flight emails; send it again to exit immediately", sig)
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+		cancel()
+	}()
+	return ctx, cancel
 }
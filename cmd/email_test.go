@@ -4,7 +4,12 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"coral.daniel-guo.com/internal/config"
+	"coral.daniel-guo.com/internal/email"
+	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -55,6 +60,196 @@ func TestSendEmailCmdFlags(t *testing.T) {
 		verboseFlag := sendEmailCmd.Flags().Lookup("verbose")
 		require.NotNil(t, verboseFlag)
 		assert.Equal(t, "v", verboseFlag.Shorthand)
+
+		optionalFlags := []string{
+			"transport",
+			"smtp-host",
+			"smtp-port",
+			"smtp-username",
+			"smtp-security",
+			"smtp-auth",
+			"mail-dir",
+			"maildir",
+			"dry-run",
+			"dry-run-dir",
+			"template-dir",
+			"period",
+			"as-of",
+			"resume",
+			"journal-dir",
+			"report",
+			"max-rate",
+			"reply-to",
+			"bcc",
+			"attachment-format",
+			"sheet",
+			"schema",
+			"column-alias",
+			"location-aliases",
+			"confirm-aliases",
+			"skip-invalid-rows",
+			"rejects",
+			"max-invalid-percent",
+			"spill-threshold",
+			"spill-dir",
+			"on-duplicate",
+			"history",
+		}
+		for _, name := range optionalFlags {
+			assert.NotNil(t, sendEmailCmd.Flags().Lookup(name), "flag %s should be defined", name)
+		}
+	})
+}
+
+func TestApplyTransportFlags(t *testing.T) {
+	t.Run("should keep ses when no transport is given", func(t *testing.T) {
+		transportFlag = ""
+		appConfig := config.NewAppConfig("dev", "", "")
+
+		applyTransportFlags(appConfig)
+
+		assert.Equal(t, email.TransportSES, appConfig.Email.Transport)
+	})
+
+	t.Run("should configure smtp transport", func(t *testing.T) {
+		t.Setenv(smtpPasswordEnv, "secret")
+		transportFlag = "smtp"
+		smtpHostFlag = "mail.example.com"
+		smtpPortFlag = 2525
+		smtpUsernameFlag = "user"
+		smtpSecurityFlag = "tls"
+		smtpAuthFlag = "login"
+		defer func() {
+			transportFlag, smtpHostFlag, smtpPortFlag, smtpUsernameFlag = "", "", 0, ""
+			smtpSecurityFlag, smtpAuthFlag = "", ""
+		}()
+		appConfig := config.NewAppConfig("dev", "", "")
+
+		applyTransportFlags(appConfig)
+
+		assert.Equal(t, "smtp", appConfig.Email.Transport)
+		assert.Equal(t, email.SMTPConfig{
+			Host:     "mail.example.com",
+			Port:     2525,
+			Username: "user",
+			Password: "secret",
+			Security: "tls",
+			Auth:     "login",
+		}, appConfig.Email.SMTP)
+	})
+}
+
+func TestApplyColumnAliases(t *testing.T) {
+	defer func() {
+		columnAliasFlag = nil
+	}()
+
+	t.Run("should add aliases to the input configuration", func(t *testing.T) {
+		columnAliasFlag = []string{"Home Club = Branch", "target club=Destination Site"}
+		appConfig := config.NewAppConfig("dev", "", "")
+
+		require.NoError(t, applyColumnAliases(appConfig))
+
+		assert.Contains(t, appConfig.Input.HeaderAliases["Home Club"], "Branch")
+		assert.Contains(t, appConfig.Input.HeaderAliases["Target Club"], "Destination Site")
+	})
+
+	t.Run("should reject malformed and unknown aliases", func(t *testing.T) {
+		columnAliasFlag = []string{"Branch"}
+		assert.EqualError(t, applyColumnAliases(config.NewAppConfig("dev", "", "")),
+			`invalid column alias "Branch": expected Column=Alias`)
+
+		columnAliasFlag = []string{"Branch=Site"}
+		err := applyColumnAliases(config.NewAppConfig("dev", "", ""))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown column "Branch"`)
+	})
+}
+
+func TestApplySchema(t *testing.T) {
+	defer func() {
+		schemaFlag = ""
+		columnAliasFlag = nil
+	}()
+
+	t.Run("should keep the default columns without a schema", func(t *testing.T) {
+		appConfig := config.NewAppConfig("dev", "", "")
+
+		require.NoError(t, applySchema(appConfig))
+
+		assert.Equal(t, schema.DefaultInput(), appConfig.Input.Columns)
+		assert.Equal(t, schema.DefaultOutput(), appConfig.Output)
+	})
+
+	t.Run("should load the schema before column aliases", func(t *testing.T) {
+		schemaFlag = filepath.Join(t.TempDir(), "schema.yaml")
+		require.NoError(t, os.WriteFile(schemaFlag, []byte(`
+input:
+  - {name: Member, field: MemberID, required: true}
+  - {name: Branch, field: HomeClub, required: true}
+  - {name: New Branch, field: TargetClub, required: true}
+output:
+  - {name: Member, field: MemberID}
+`), 0o644))
+		columnAliasFlag = []string{"Branch=Site"}
+		appConfig := config.NewAppConfig("dev", "", "")
+
+		require.NoError(t, applySchema(appConfig))
+		require.NoError(t, applyColumnAliases(appConfig))
+
+		assert.Equal(t, []string{"Member", "Branch", "New Branch"}, appConfig.Input.Columns.Names())
+		assert.Equal(t, []string{"Member"}, appConfig.Output.Names())
+		assert.Contains(t, appConfig.Input.HeaderAliases["Branch"], "Site")
+	})
+
+	t.Run("should fail for a missing schema file", func(t *testing.T) {
+		schemaFlag = filepath.Join(t.TempDir(), "missing.yaml")
+		err := applySchema(config.NewAppConfig("dev", "", ""))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read schema")
+	})
+}
+
+func TestApplyLocationAliases(t *testing.T) {
+	defer func() {
+		locationAliasesFlag = ""
+		confirmAliasesFlag = false
+	}()
+
+	t.Run("should load the location aliases", func(t *testing.T) {
+		locationAliasesFlag = filepath.Join(t.TempDir(), "aliases.yaml")
+		require.NoError(t, os.WriteFile(locationAliasesFlag, []byte("CBD: Sydney CBD\n"), 0o644))
+		appConfig := config.NewAppConfig("dev", "", "")
+
+		require.NoError(t, applyLocationAliases(appConfig))
+		assert.Equal(t, "Sydney CBD", appConfig.LocationAliases.Resolve("cbd"))
+		assert.Equal(t, locationAliasesFlag, appConfig.LocationAliasesPath)
+		assert.False(t, appConfig.ConfirmAliases)
+	})
+
+	t.Run("should fail for a missing file", func(t *testing.T) {
+		locationAliasesFlag = filepath.Join(t.TempDir(), "aliases.yaml")
+		assert.Error(t, applyLocationAliases(config.NewAppConfig("dev", "", "")))
+	})
+
+	t.Run("should start a missing file when confirming aliases", func(t *testing.T) {
+		locationAliasesFlag = filepath.Join(t.TempDir(), "aliases.yaml")
+		confirmAliasesFlag = true
+		appConfig := config.NewAppConfig("dev", "", "")
+
+		require.NoError(t, applyLocationAliases(appConfig))
+		assert.True(t, appConfig.ConfirmAliases)
+		assert.Empty(t, appConfig.LocationAliases)
+		confirmAliasesFlag = false
+	})
+
+	t.Run("should fail for an invalid file", func(t *testing.T) {
+		locationAliasesFlag = filepath.Join(t.TempDir(), "aliases.txt")
+		require.NoError(t, os.WriteFile(locationAliasesFlag, []byte("CBD=Sydney CBD\n"), 0o644))
+
+		err := applyLocationAliases(config.NewAppConfig("dev", "", ""))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported location aliases file")
 	})
 }
 
@@ -91,3 +286,47 @@ func TestSendEmailCmdValidation(t *testing.T) {
 		})
 	})
 }
+
+func TestNewTransferRequest(t *testing.T) {
+	defer func() {
+		typeFlag, inputFlag, periodFlag, asOfFlag = "", "", "", ""
+	}()
+
+	t.Run("should leave period and as-of unset by default", func(t *testing.T) {
+		typeFlag, inputFlag, periodFlag, asOfFlag = "PIF", "input.csv", "", ""
+
+		req, err := newTransferRequest()
+
+		require.NoError(t, err)
+		assert.Equal(t, "PIF", req.TransferType)
+		assert.Equal(t, "input.csv", req.FileName)
+		assert.True(t, req.Period.IsZero())
+		assert.True(t, req.AsOf.IsZero())
+	})
+
+	t.Run("should parse period and as-of", func(t *testing.T) {
+		typeFlag, inputFlag, periodFlag, asOfFlag = "DD", "input.csv", "2025-Q2", "2025-07-01"
+
+		req, err := newTransferRequest()
+
+		require.NoError(t, err)
+		assert.Equal(t, model.NewQuarterPeriod(2025, 2), req.Period)
+		assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), req.AsOf)
+	})
+
+	t.Run("should reject invalid period", func(t *testing.T) {
+		periodFlag, asOfFlag = "2025-Q7", ""
+
+		_, err := newTransferRequest()
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject invalid as-of", func(t *testing.T) {
+		periodFlag, asOfFlag = "", "01/07/2025"
+
+		_, err := newTransferRequest()
+
+		assert.EqualError(t, err, `invalid as-of date "01/07/2025": expected YYYY-MM-DD`)
+	})
+}
@@ -27,4 +27,5 @@ func Execute() {
 
 func init() {
 	rootCmd.AddCommand(sendEmailCmd)
+	rootCmd.AddCommand(validateCmd)
 }
@@ -25,6 +25,12 @@ func TestRootCmd(t *testing.T) {
 		}
 		assert.True(t, foundSendEmail, "send-email command should be added to root command")
 	})
+
+	t.Run("should have validate subcommand", func(t *testing.T) {
+		cmd, _, err := rootCmd.Find([]string{"validate"})
+		assert.NoError(t, err)
+		assert.Equal(t, validateCmd, cmd)
+	})
 }
 
 func TestExecute(t *testing.T) {
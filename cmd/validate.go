@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"os"
+
+	"coral.daniel-guo.com/internal/config"
+	"coral.daniel-guo.com/internal/logger"
+	"coral.daniel-guo.com/internal/service"
+	"github.com/spf13/cobra"
+)
+
+// validateCmd represents the validate command for pre-flight checking a transfer file
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate club transfer data without sending emails",
+	Long: `Run the pre-flight checks for a club transfer file without sending any email.
+This command checks that every row has the required fields, every club resolves
+to a location with a valid email address and the sender address is valid, and
+lists every problem found. With --skip-invalid-rows it passes or fails as the
+send would, writing the invalid rows to --rejects.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if verboseFlag {
+			logger.SetLevel(logger.DebugLevel)
+		}
+
+		logger.Info("Validating transfer type: %s, filename: %s, env: %s",
+			typeFlag, inputFlag, envFlag)
+
+		appConfig := config.NewAppConfig(envFlag, testEmailFlag, senderFlag)
+		err := applySchema(appConfig)
+		if err == nil {
+			err = applyColumnAliases(appConfig)
+		}
+		if err == nil {
+			err = applyLocationAliases(appConfig)
+		}
+		if err != nil {
+			logger.Error("Invalid arguments: %v", err)
+			os.Exit(1)
+		}
+		appConfig.AttachmentFormat = attachmentFormatFlag
+		appConfig.SkipInvalidRows = skipInvalidRowsFlag
+		appConfig.RejectsPath = rejectsFlag
+		appConfig.MaxInvalidPercent = maxInvalidPercentFlag
+		appConfig.Grouping.MaxInMemory = spillThresholdFlag
+		appConfig.Grouping.Dir = spillDirFlag
+		appConfig.OnDuplicate = onDuplicateFlag
+		appConfig.HistoryPath = historyFlag
+		transferService := service.NewService(appConfig)
+
+		// Validate for the same period as the send so the history check
+		// ignores that period's own transfers
+		req, err := newTransferRequest()
+		if err != nil {
+			logger.Error("Invalid arguments: %v", err)
+			os.Exit(1)
+		}
+
+		ctx, stop := notifyContext(cmd.Context())
+		defer stop()
+
+		if err := transferService.Validate(ctx, req); err != nil {
+			logger.Error("Validation failed: %v", err)
+			stop()
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	validateCmd.Flags().
+		StringVarP(&typeFlag, "type", "t", "", "Club transfer type: PIF (Paid in Full) or DD (Direct Debit)")
+	validateCmd.Flags().StringVarP(&inputFlag, "input", "i", "", "CSV or XLSX input file with transfer data")
+	validateCmd.Flags().
+		StringVarP(&sheetFlag, "sheet", "", "", "Sheet of an XLSX input file, by name or 1-based position (default: first)")
+	validateCmd.Flags().
+		StringVarP(&periodFlag, "period", "", "", "Reporting period, e.g. 2025-05 or 2025-Q2 (default: derived from --as-of)")
+	validateCmd.Flags().
+		StringVarP(&asOfFlag, "as-of", "", "", "Date the run is treated as happening on, YYYY-MM-DD (default: today)")
+	validateCmd.Flags().
+		StringVarP(&schemaFlag, "schema", "", "", "YAML or JSON file declaring the input and output columns")
+	validateCmd.Flags().
+		StringArrayVarP(&columnAliasFlag, "column-alias", "", nil, `Extra input header name, e.g. "Home Club=Branch"`)
+	validateCmd.Flags().
+		StringVarP(&locationAliasesFlag, "location-aliases", "", "", "YAML or JSON file mapping club to location names")
+	validateCmd.Flags().
+		BoolVarP(&confirmAliasesFlag, "confirm-aliases", "", false, "Ask which suggested location an unknown club is")
+	validateCmd.Flags().
+		BoolVarP(&skipInvalidRowsFlag, "skip-invalid-rows", "", false, "Skip invalid input rows instead of aborting")
+	validateCmd.Flags().
+		StringVarP(&rejectsFlag, "rejects", "", "rejects.csv", "File the skipped invalid rows are written to")
+	validateCmd.Flags().
+		Float64VarP(&maxInvalidPercentFlag, "max-invalid-percent", "", 10, "Abort if over this percent of rows is invalid")
+	validateCmd.Flags().
+		IntVarP(&spillThresholdFlag, "spill-threshold", "", 100000, "Transfers in memory before spilling to disk")
+	validateCmd.Flags().
+		StringVarP(&spillDirFlag, "spill-dir", "", "", "Directory for spilled transfer files (default: system temp dir)")
+	validateCmd.Flags().
+		StringVarP(&onDuplicateFlag, "on-duplicate", "", "warn", "Duplicate or already notified rows: skip, warn or fail")
+	validateCmd.Flags().
+		StringVarP(&historyFlag, "history", "", ".journal/history.jsonl", "File recording transfers already notified")
+	validateCmd.Flags().
+		StringVarP(&attachmentFormatFlag, "attachment-format", "", "csv", "Attachment format: csv or xlsx")
+	validateCmd.Flags().StringVarP(&senderFlag, "sender", "s", "", "Sender email address")
+	validateCmd.Flags().StringVarP(&envFlag, "env", "e", "", "Environment (dev, staging, prod)")
+	validateCmd.Flags().
+		StringVarP(&testEmailFlag, "test-email", "", "", "Test email address to validate")
+	validateCmd.Flags().BoolVarP(&verboseFlag, "verbose", "v", false, "Enable verbose debugging output")
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCmd(t *testing.T) {
+	t.Run("should have correct usage", func(t *testing.T) {
+		assert.Equal(t, "validate", validateCmd.Use)
+		assert.Equal(t, "Validate club transfer data without sending emails", validateCmd.Short)
+		assert.NotNil(t, validateCmd.Run)
+	})
+
+	t.Run("should share the input flags with send-email", func(t *testing.T) {
+		for _, name := range []string{
+			"type", "input", "sheet", "period", "as-of", "schema", "column-alias", "location-aliases", "confirm-aliases",
+			"skip-invalid-rows", "rejects", "max-invalid-percent", "spill-threshold", "spill-dir", "on-duplicate", "history",
+			"attachment-format", "sender", "env", "test-email", "verbose",
+		} {
+			flag := validateCmd.Flags().Lookup(name)
+			require.NotNil(t, flag, "flag %s should be defined", name)
+			assert.Equal(t, sendEmailCmd.Flags().Lookup(name).Shorthand, flag.Shorthand)
+		}
+	})
+}
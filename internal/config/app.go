@@ -2,8 +2,14 @@
 package config
 
 import (
+	"coral.daniel-guo.com/internal/csvutil"
 	"coral.daniel-guo.com/internal/email"
+	"coral.daniel-guo.com/internal/grouping"
+	"coral.daniel-guo.com/internal/locations"
+	"coral.daniel-guo.com/internal/retry"
+	"coral.daniel-guo.com/internal/schema"
 	"coral.daniel-guo.com/internal/secrets"
+	"coral.daniel-guo.com/internal/templates"
 )
 
 // AppConfig holds the application configuration
@@ -17,27 +23,104 @@ type AppConfig struct {
 	// Secrets manager configuration
 	Secrets secrets.Config
 
+	// Email subject and body template configuration
+	Templates templates.Config
+
+	// Input file columns and header configuration
+	Input csvutil.Config
+
+	// Output lists the columns of the attachment files in order
+	Output schema.Columns
+
+	// LocationAliases maps club names of the input file to location names.
+	// With ConfirmAliases the operator is asked which suggested location a
+	// club without one is, and the answers are saved to LocationAliasesPath.
+	LocationAliases     locations.Aliases
+	LocationAliasesPath string
+	ConfirmAliases      bool
+
 	// Default sender email address
 	DefaultSender string
 
 	// Test email (if set, all emails go here)
 	TestEmail string
 
+	// Dry run renders every email into DryRunDir instead of sending it
+	DryRun    bool
+	DryRunDir string
+
+	// Directory for run journals recording each club's delivery outcome
+	JournalDir string
+
+	// Path of the per-club run report, written as CSV for .csv paths and JSON otherwise
+	ReportPath string
+
+	// Format of the transfer file attached to each club email (csv or xlsx)
+	AttachmentFormat string
+
+	// SkipInvalidRows drops input rows that fail validation instead of
+	// failing the run, writing them to RejectsPath. The run still fails when
+	// more than MaxInvalidPercent of the rows are invalid.
+	SkipInvalidRows   bool
+	RejectsPath       string
+	MaxInvalidPercent float64
+
+	// OnDuplicate is what to do with a row repeating an earlier row of the
+	// input file or a transfer notified by a previous run (skip, warn or
+	// fail). Notified transfers are recorded in HistoryPath.
+	OnDuplicate string
+	HistoryPath string
+
+	// Grouping configures when per-club transfers are spilled to temporary
+	// files while the input file is read
+	Grouping grouping.Config
+
+	// Retry policy for transient email and database errors
+	Retry retry.Policy
+
 	// Worker pool configuration
 	WorkerPoolSize int
-	WorkerDelayMs  int
+
+	// Maximum emails per second shared by all workers, with bursts of up to
+	// RateBurst emails. A MaxRate of 0 uses the SES sending quota, falling
+	// back to FallbackMaxRate when the quota cannot be read, and does not
+	// limit transports without a quota.
+	MaxRate   float64
+	RateBurst int
 }
 
+// Supported attachment formats
+const (
+	AttachmentFormatCSV  = "csv"
+	AttachmentFormatXLSX = "xlsx"
+)
+
+// Supported duplicate actions
+const (
+	OnDuplicateSkip = "skip"
+	OnDuplicateWarn = "warn"
+	OnDuplicateFail = "fail"
+)
+
+// FallbackMaxRate is the sending rate used when no rate is configured and the
+// sending quota of a rate limited transport cannot be read
+const FallbackMaxRate = 1.0
+
 // NewAppConfig creates a new application configuration with default values
 func NewAppConfig(environment string, testEmail string, sender string) *AppConfig {
 	cfg := &AppConfig{
-		Environment:    environment,
-		Email:          email.DefaultConfig(),
-		Secrets:        secrets.DefaultConfig(),
-		DefaultSender:  "no-reply@the-hub.ai",
-		TestEmail:      "",
-		WorkerPoolSize: 5,
-		WorkerDelayMs:  1000,
+		Environment:      environment,
+		Email:            email.DefaultConfig(),
+		Secrets:          secrets.DefaultConfig(),
+		Input:            csvutil.DefaultConfig(),
+		Output:           schema.DefaultOutput(),
+		Grouping:         grouping.DefaultConfig(),
+		Retry:            retry.DefaultPolicy(),
+		DefaultSender:    "no-reply@the-hub.ai",
+		TestEmail:        "",
+		AttachmentFormat: AttachmentFormatCSV,
+		OnDuplicate:      OnDuplicateWarn,
+		WorkerPoolSize:   5,
 	}
 	if testEmail != "" {
 		cfg.TestEmail = testEmail
@@ -47,3 +130,9 @@ func NewAppConfig(environment string, testEmail string, sender string) *AppConfi
 	}
 	return cfg
 }
+
+// ApplySchema replaces the input and output columns with those of the schema
+func (c *AppConfig) ApplySchema(s schema.Schema) {
+	c.Input.Columns = s.Input
+	c.Output = s.Output
+}
@@ -3,7 +3,11 @@ package config
 import (
 	"testing"
 
+	"coral.daniel-guo.com/internal/csvutil"
 	"coral.daniel-guo.com/internal/email"
+	"coral.daniel-guo.com/internal/grouping"
+	"coral.daniel-guo.com/internal/retry"
+	"coral.daniel-guo.com/internal/schema"
 	"coral.daniel-guo.com/internal/secrets"
 )
 
@@ -21,13 +25,19 @@ func TestNewAppConfig(t *testing.T) {
 			testEmail:   "",
 			sender:      "",
 			expected: &AppConfig{
-				Environment:    "dev",
-				Email:          email.DefaultConfig(),
-				Secrets:        secrets.DefaultConfig(),
-				DefaultSender:  "no-reply@the-hub.ai",
-				TestEmail:      "",
-				WorkerPoolSize: 5,
-				WorkerDelayMs:  1000,
+				Environment:      "dev",
+				Email:            email.DefaultConfig(),
+				Secrets:          secrets.DefaultConfig(),
+				Input:            csvutil.DefaultConfig(),
+				Output:           schema.DefaultOutput(),
+				Grouping:         grouping.DefaultConfig(),
+				Retry:            retry.DefaultPolicy(),
+				DefaultSender:    "no-reply@the-hub.ai",
+				TestEmail:        "",
+				AttachmentFormat: AttachmentFormatCSV,
+				OnDuplicate:      OnDuplicateWarn,
+				WorkerPoolSize:   5,
+				MaxRate:          0,
 			},
 		},
 		{
@@ -36,13 +46,19 @@ func TestNewAppConfig(t *testing.T) {
 			testEmail:   "test@example.com",
 			sender:      "",
 			expected: &AppConfig{
-				Environment:    "test",
-				Email:          email.DefaultConfig(),
-				Secrets:        secrets.DefaultConfig(),
-				DefaultSender:  "no-reply@the-hub.ai",
-				TestEmail:      "test@example.com",
-				WorkerPoolSize: 5,
-				WorkerDelayMs:  1000,
+				Environment:      "test",
+				Email:            email.DefaultConfig(),
+				Secrets:          secrets.DefaultConfig(),
+				Input:            csvutil.DefaultConfig(),
+				Output:           schema.DefaultOutput(),
+				Grouping:         grouping.DefaultConfig(),
+				Retry:            retry.DefaultPolicy(),
+				DefaultSender:    "no-reply@the-hub.ai",
+				TestEmail:        "test@example.com",
+				AttachmentFormat: AttachmentFormatCSV,
+				OnDuplicate:      OnDuplicateWarn,
+				WorkerPoolSize:   5,
+				MaxRate:          0,
 			},
 		},
 		{
@@ -51,13 +67,19 @@ func TestNewAppConfig(t *testing.T) {
 			testEmail:   "",
 			sender:      "custom@sender.com",
 			expected: &AppConfig{
-				Environment:    "prod",
-				Email:          email.DefaultConfig(),
-				Secrets:        secrets.DefaultConfig(),
-				DefaultSender:  "custom@sender.com",
-				TestEmail:      "",
-				WorkerPoolSize: 5,
-				WorkerDelayMs:  1000,
+				Environment:      "prod",
+				Email:            email.DefaultConfig(),
+				Secrets:          secrets.DefaultConfig(),
+				Input:            csvutil.DefaultConfig(),
+				Output:           schema.DefaultOutput(),
+				Grouping:         grouping.DefaultConfig(),
+				Retry:            retry.DefaultPolicy(),
+				DefaultSender:    "custom@sender.com",
+				TestEmail:        "",
+				AttachmentFormat: AttachmentFormatCSV,
+				OnDuplicate:      OnDuplicateWarn,
+				WorkerPoolSize:   5,
+				MaxRate:          0,
 			},
 		},
 		{
@@ -66,13 +88,19 @@ func TestNewAppConfig(t *testing.T) {
 			testEmail:   "test@staging.com",
 			sender:      "staging@sender.com",
 			expected: &AppConfig{
-				Environment:    "staging",
-				Email:          email.DefaultConfig(),
-				Secrets:        secrets.DefaultConfig(),
-				DefaultSender:  "staging@sender.com",
-				TestEmail:      "test@staging.com",
-				WorkerPoolSize: 5,
-				WorkerDelayMs:  1000,
+				Environment:      "staging",
+				Email:            email.DefaultConfig(),
+				Secrets:          secrets.DefaultConfig(),
+				Input:            csvutil.DefaultConfig(),
+				Output:           schema.DefaultOutput(),
+				Grouping:         grouping.DefaultConfig(),
+				Retry:            retry.DefaultPolicy(),
+				DefaultSender:    "staging@sender.com",
+				TestEmail:        "test@staging.com",
+				AttachmentFormat: AttachmentFormatCSV,
+				OnDuplicate:      OnDuplicateWarn,
+				WorkerPoolSize:   5,
+				MaxRate:          0,
 			},
 		},
 		{
@@ -81,13 +109,19 @@ func TestNewAppConfig(t *testing.T) {
 			testEmail:   "",
 			sender:      "",
 			expected: &AppConfig{
-				Environment:    "",
-				Email:          email.DefaultConfig(),
-				Secrets:        secrets.DefaultConfig(),
-				DefaultSender:  "no-reply@the-hub.ai",
-				TestEmail:      "",
-				WorkerPoolSize: 5,
-				WorkerDelayMs:  1000,
+				Environment:      "",
+				Email:            email.DefaultConfig(),
+				Secrets:          secrets.DefaultConfig(),
+				Input:            csvutil.DefaultConfig(),
+				Output:           schema.DefaultOutput(),
+				Grouping:         grouping.DefaultConfig(),
+				Retry:            retry.DefaultPolicy(),
+				DefaultSender:    "no-reply@the-hub.ai",
+				TestEmail:        "",
+				AttachmentFormat: AttachmentFormatCSV,
+				OnDuplicate:      OnDuplicateWarn,
+				WorkerPoolSize:   5,
+				MaxRate:          0,
 			},
 		},
 	}
@@ -109,8 +143,8 @@ func TestNewAppConfig(t *testing.T) {
 			if got.WorkerPoolSize != tt.expected.WorkerPoolSize {
 				t.Errorf("WorkerPoolSize = %v, want %v", got.WorkerPoolSize, tt.expected.WorkerPoolSize)
 			}
-			if got.WorkerDelayMs != tt.expected.WorkerDelayMs {
-				t.Errorf("WorkerDelayMs = %v, want %v", got.WorkerDelayMs, tt.expected.WorkerDelayMs)
+			if got.MaxRate != tt.expected.MaxRate {
+				t.Errorf("MaxRate = %v, want %v", got.MaxRate, tt.expected.MaxRate)
 			}
 
 			// Verify Email config is set with default values
@@ -152,7 +186,7 @@ func TestAppConfig_DefaultValues(t *testing.T) {
 	// Test default values
 	expectedDefaults := map[string]interface{}{
 		"WorkerPoolSize": 5,
-		"WorkerDelayMs":  1000,
+		"MaxRate":        0.0,
 		"DefaultSender":  "no-reply@the-hub.ai",
 		"TestEmail":      "",
 		"Environment":    "test",
@@ -160,7 +194,7 @@ func TestAppConfig_DefaultValues(t *testing.T) {
 
 	actualValues := map[string]interface{}{
 		"WorkerPoolSize": config.WorkerPoolSize,
-		"WorkerDelayMs":  config.WorkerDelayMs,
+		"MaxRate":        config.MaxRate,
 		"DefaultSender":  config.DefaultSender,
 		"TestEmail":      config.TestEmail,
 		"Environment":    config.Environment,
@@ -201,7 +235,8 @@ func TestAppConfig_StructFields(t *testing.T) {
 	var _ = config.DefaultSender
 	var _ = config.TestEmail
 	var _ = config.WorkerPoolSize
-	var _ = config.WorkerDelayMs
+	var _ = config.MaxRate
+	var _ = config.RateBurst
 
 	// This test ensures that if struct fields change, the test will break
 	// and force us to update the tests accordingly
@@ -5,14 +5,17 @@ import (
 	"bytes"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
-	"strings"
+	"time"
 
 	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/schema"
 )
 
-// ReadClubTransferCSV reads a CSV file with club transfer data
-func ReadClubTransferCSV(fileName string) ([]model.ClubTransferRow, error) {
+// ReadClubTransferCSV reads a CSV file with club transfer data. A leading
+// UTF-8 byte order mark is skipped.
+func ReadClubTransferCSV(fileName string, config Config) ([]model.ClubTransferRow, error) {
 	file, err := os.Open(fileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
@@ -24,92 +27,57 @@ func ReadClubTransferCSV(fileName string) ([]model.ClubTransferRow, error) {
 		}
 	}()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+	reader, err := NewCSVReader(file, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
-	}
-
-	if len(records) == 0 {
-		return nil, fmt.Errorf("no records found")
-	}
-
-	headers := records[0]
-	colMap := make(map[string]int)
-	for i, colName := range headers {
-		colMap[colName] = i
-	}
-
-	requiredCols := []string{
-		"Member Id",
-		"Fob Number",
-		"First Name",
-		"Last Name",
-		"Membership Type",
-		"Home Club",
-		"Target Club",
+		return nil, err
 	}
+	return reader.Collect()
+}
 
-	for _, col := range requiredCols {
-		if _, ok := colMap[col]; !ok {
-			return nil, fmt.Errorf("column %s not found", col)
+// ParseClubTransferRecords maps records with a header row to club transfer
+// rows, matching the required columns by name or alias. Records shorter
+// than the header, as spreadsheets produce for trailing empty cells, are
+// padded with empty fields and empty records are skipped. Each row's Line
+// is its 1-based position in records.
+func ParseClubTransferRecords(records [][]string, config Config) ([]model.ClubTransferRow, error) {
+	i := 0
+	reader, err := NewClubTransferReader(func() ([]string, int, error) {
+		if i == len(records) {
+			return nil, 0, io.EOF
 		}
+		i++
+		return records[i-1], i, nil
+	}, config)
+	if err != nil {
+		return nil, err
 	}
+	return reader.Collect()
+}
 
-	var result []model.ClubTransferRow
-	for i, record := range records {
-		if i == 0 {
-			continue
-		}
-
-		row := model.ClubTransferRow{
-			MemberID:       record[colMap["Member Id"]],
-			FobNumber:      record[colMap["Fob Number"]],
-			FirstName:      record[colMap["First Name"]],
-			LastName:       record[colMap["Last Name"]],
-			MembershipType: record[colMap["Membership Type"]],
-			HomeClub:       strings.ToUpper(record[colMap["Home Club"]]),
-			TargetClub:     strings.ToUpper(record[colMap["Target Club"]]),
-		}
-
-		result = append(result, row)
+// GenerateCSVContent generates CSV content in memory as []byte with the
+// given output columns, or the default ones when columns is empty. Dates
+// are written as YYYY-MM-DD.
+func GenerateCSVContent(data []model.ClubTransferData, columns schema.Columns) ([]byte, error) {
+	if len(columns) == 0 {
+		columns = schema.DefaultOutput()
 	}
 
-	return result, nil
-}
-
-// GenerateCSVContent generates CSV content in memory as []byte
-func GenerateCSVContent(data []model.ClubTransferData) ([]byte, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
-	headers := []string{
-		"Member Id",
-		"Fob Number",
-		"First Name",
-		"Last Name",
-		"Membership Type",
-		"Home Club",
-		"Target Club",
-		"Transfer Type",
-		"Transfer Date",
-	}
-
-	if err := writer.Write(headers); err != nil {
+	if err := writer.Write(columns.Names()); err != nil {
 		return nil, fmt.Errorf("failed to write headers: %w", err)
 	}
 
 	for _, transfer := range data {
-		record := []string{
-			transfer.MemberID,
-			transfer.FobNumber,
-			transfer.FirstName,
-			transfer.LastName,
-			transfer.MembershipType,
-			transfer.HomeClub,
-			transfer.TargetClub,
-			transfer.TransferType,
-			transfer.TransferDate.Format("2006-01-02"),
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			switch value := column.DataValue(transfer).(type) {
+			case time.Time:
+				record[i] = value.Format("2006-01-02")
+			default:
+				record[i] = fmt.Sprint(value)
+			}
 		}
 
 		if err := writer.Write(record); err != nil {
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -43,7 +44,7 @@ func (suite *CSVUtilTestSuite) TestReadClubTransferCSVSuccess() {
 
 	filePath := suite.createTestCSVFile("test.csv", csvContent)
 
-	result, err := ReadClubTransferCSV(filePath)
+	result, err := ReadClubTransferCSV(filePath, DefaultConfig())
 
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), result, 2)
@@ -64,7 +65,7 @@ func (suite *CSVUtilTestSuite) TestReadClubTransferCSVSuccess() {
 }
 
 func (suite *CSVUtilTestSuite) TestReadClubTransferCSVFileNotFound() {
-	_, err := ReadClubTransferCSV("nonexistent.csv")
+	_, err := ReadClubTransferCSV("nonexistent.csv", DefaultConfig())
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "failed to open file")
 }
@@ -72,7 +73,7 @@ func (suite *CSVUtilTestSuite) TestReadClubTransferCSVFileNotFound() {
 func (suite *CSVUtilTestSuite) TestReadClubTransferCSVEmptyFile() {
 	filePath := suite.createTestCSVFile("empty.csv", "")
 
-	_, err := ReadClubTransferCSV(filePath)
+	_, err := ReadClubTransferCSV(filePath, DefaultConfig())
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "no records found")
 }
@@ -83,7 +84,7 @@ func (suite *CSVUtilTestSuite) TestReadClubTransferCSVMissingColumns() {
 
 	filePath := suite.createTestCSVFile("missing_cols.csv", csvContent)
 
-	_, err := ReadClubTransferCSV(filePath)
+	_, err := ReadClubTransferCSV(filePath, DefaultConfig())
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "column")
 	assert.Contains(suite.T(), err.Error(), "not found")
@@ -95,11 +96,52 @@ func (suite *CSVUtilTestSuite) TestReadClubTransferCSVInvalidCSV() {
 
 	filePath := suite.createTestCSVFile("invalid.csv", csvContent)
 
-	_, err := ReadClubTransferCSV(filePath)
+	_, err := ReadClubTransferCSV(filePath, DefaultConfig())
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "failed to read file")
 }
 
+func (suite *CSVUtilTestSuite) TestReadClubTransferCSVLineNumbers() {
+	csvContent := "Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club\n" +
+		"12345,FOB001,John,Doe,Premium,CLUB A,CLUB B\n" +
+		"\n" +
+		"67890,FOB002,\"Jane\nMary\",Smith,Standard,CLUB C,CLUB D\n" +
+		"11111,FOB003,Bob\n"
+
+	filePath := suite.createTestCSVFile("lines.csv", csvContent)
+
+	result, err := ReadClubTransferCSV(filePath, DefaultConfig())
+
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), result, 3)
+	assert.Equal(suite.T(), 2, result[0].Line)
+	assert.Equal(suite.T(), 4, result[1].Line)
+	// A short row is read with empty fields for validation to report
+	assert.Equal(suite.T(), 6, result[2].Line)
+	assert.Equal(suite.T(), "Bob", result[2].FirstName)
+	assert.Empty(suite.T(), result[2].TargetClub)
+}
+
+func (suite *CSVUtilTestSuite) TestParseClubTransferRecordsShortRecords() {
+	records := [][]string{
+		{"Member Id", "Fob Number", "First Name", "Last Name", "Membership Type", "Home Club", "Target Club"},
+		{"12345", "FOB001", "John", "Doe", "Premium", "club a"},
+		{},
+		{"67890"},
+	}
+
+	result, err := ParseClubTransferRecords(records, DefaultConfig())
+
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), result, 2)
+	assert.Equal(suite.T(), "CLUB A", result[0].HomeClub)
+	assert.Equal(suite.T(), "", result[0].TargetClub)
+	assert.Equal(suite.T(), model.ClubTransferRow{MemberID: "67890", Line: 4}, result[1])
+
+	_, err = ParseClubTransferRecords(nil, DefaultConfig())
+	assert.EqualError(suite.T(), err, "no records found")
+}
+
 func (suite *CSVUtilTestSuite) TestGenerateCSVContentSuccess() {
 	transferDate := time.Date(2023, 12, 15, 10, 30, 0, 0, time.UTC)
 
@@ -128,7 +170,7 @@ func (suite *CSVUtilTestSuite) TestGenerateCSVContentSuccess() {
 		},
 	}
 
-	result, err := GenerateCSVContent(data)
+	result, err := GenerateCSVContent(data, nil)
 
 	assert.NoError(suite.T(), err)
 	assert.NotEmpty(suite.T(), result)
@@ -153,7 +195,7 @@ func (suite *CSVUtilTestSuite) TestGenerateCSVContentSuccess() {
 func (suite *CSVUtilTestSuite) TestGenerateCSVContentEmpty() {
 	data := []model.ClubTransferData{}
 
-	result, err := GenerateCSVContent(data)
+	result, err := GenerateCSVContent(data, nil)
 
 	assert.NoError(suite.T(), err)
 	assert.NotEmpty(suite.T(), result)
@@ -184,7 +226,7 @@ func (suite *CSVUtilTestSuite) TestGenerateCSVContentSpecialCharacters() {
 		},
 	}
 
-	result, err := GenerateCSVContent(data)
+	result, err := GenerateCSVContent(data, nil)
 
 	assert.NoError(suite.T(), err)
 	assert.NotEmpty(suite.T(), result)
@@ -196,6 +238,24 @@ func (suite *CSVUtilTestSuite) TestGenerateCSVContentSpecialCharacters() {
 	assert.Contains(suite.T(), csvString, "\"Premium \"\"VIP\"\"\"")
 }
 
+func (suite *CSVUtilTestSuite) TestGenerateCSVContentCustomColumns() {
+	data := []model.ClubTransferData{{
+		MemberID:     "12345",
+		HomeClub:     "CLUB A",
+		TransferType: "TRANSFER OUT",
+		TransferDate: time.Date(2023, 12, 15, 10, 30, 0, 0, time.UTC),
+	}}
+
+	result, err := GenerateCSVContent(data, schema.Columns{
+		{Name: "Date", Field: "TransferDate"},
+		{Name: "Member", Field: "MemberID"},
+		{Name: "Club", Field: "HomeClub"},
+	})
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Date,Member,Club\n2023-12-15,12345,CLUB A\n", string(result))
+}
+
 func TestCSVUtilSuite(t *testing.T) {
 	suite.Run(t, new(CSVUtilTestSuite))
 }
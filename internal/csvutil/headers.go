@@ -0,0 +1,130 @@
+package csvutil
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/schema"
+)
+
+// utf8BOM is the byte order mark some spreadsheet exports start with
+const utf8BOM = "\uFEFF"
+
+// Config configures the columns of an input file and how its header row is matched
+type Config struct {
+	// Columns declares the input columns and the row fields they map onto;
+	// the default schema columns when empty
+	Columns schema.Columns
+
+	// HeaderAliases maps an input column to other names it may have in
+	// the header row, in addition to the aliases declared by the column.
+	// Names are matched ignoring case, spaces, underscores, hyphens and dots,
+	// so "Member ID" and "member_id" always match "Member Id" without an
+	// alias.
+	HeaderAliases map[string][]string
+}
+
+// DefaultConfig returns a default input configuration
+func DefaultConfig() Config {
+	return Config{Columns: schema.DefaultInput(), HeaderAliases: DefaultHeaderAliases()}
+}
+
+// DefaultHeaderAliases returns the column names used by the membership
+// systems transfer lists are exported from
+func DefaultHeaderAliases() map[string][]string {
+	return map[string][]string{
+		"Member Id":       {"Member Number", "Member No", "Membership Id", "Membership Number"},
+		"Fob Number":      {"Fob", "Fob No", "Fob Id", "Key Fob", "Access Fob"},
+		"First Name":      {"Given Name", "Forename"},
+		"Last Name":       {"Surname", "Family Name"},
+		"Membership Type": {"Membership", "Membership Plan", "Plan"},
+		"Home Club":       {"Home Location", "Current Club", "From Club"},
+		"Target Club":     {"Target Location", "New Club", "To Club", "Destination Club"},
+	}
+}
+
+// columns returns the configured input columns, or the default ones
+func (c Config) columns() schema.Columns {
+	if len(c.Columns) == 0 {
+		return schema.DefaultInput()
+	}
+	return c.Columns
+}
+
+// ValidateRow checks a row against the input columns and returns the
+// reasons it is invalid, if any
+func (c Config) ValidateRow(row model.ClubTransferRow) []string {
+	return c.columns().Validate(row)
+}
+
+// AddAlias adds an alternative name for an input column
+func (c *Config) AddAlias(column, alias string) error {
+	canonical := ""
+	for _, col := range c.columns() {
+		if normalizeHeader(col.Name) == normalizeHeader(column) {
+			canonical = col.Name
+		}
+	}
+	if canonical == "" {
+		return fmt.Errorf("unknown column %q, expected one of: %s", column, strings.Join(c.columns().Names(), ", "))
+	}
+
+	if c.HeaderAliases == nil {
+		c.HeaderAliases = make(map[string][]string)
+	}
+	c.HeaderAliases[canonical] = append(c.HeaderAliases[canonical], alias)
+	return nil
+}
+
+// columnIndex maps the input columns found in the header row to their
+// positions. Every required column must be found.
+func (c Config) columnIndex(headers []string) (map[string]int, error) {
+	positions := make(map[string]int, len(headers))
+	for i, header := range headers {
+		key := normalizeHeader(header)
+		if _, ok := positions[key]; !ok {
+			positions[key] = i
+		}
+	}
+
+	colMap := make(map[string]int, len(c.columns()))
+	for _, col := range c.columns() {
+		found := false
+		for _, name := range append([]string{col.Name}, c.aliases(col)...) {
+			if i, ok := positions[normalizeHeader(name)]; ok {
+				colMap[col.Name] = i
+				found = true
+				break
+			}
+		}
+		if !found && col.Required {
+			return nil, fmt.Errorf("column %s not found", col.Name)
+		}
+	}
+	return colMap, nil
+}
+
+// aliases returns the aliases of an input column, whatever the case of the configured key
+func (c Config) aliases(column schema.Column) []string {
+	aliases := append([]string(nil), column.Aliases...)
+	for key, names := range c.HeaderAliases {
+		if normalizeHeader(key) == normalizeHeader(column.Name) {
+			aliases = append(aliases, names...)
+		}
+	}
+	return aliases
+}
+
+// normalizeHeader lower-cases a column name and drops a byte order mark,
+// whitespace and separators
+func normalizeHeader(header string) string {
+	header = strings.TrimPrefix(header, utf8BOM)
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '_' || r == '-' || r == '.' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, header)
+}
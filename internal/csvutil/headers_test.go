@@ -0,0 +1,116 @@
+package csvutil
+
+import (
+	"path/filepath"
+	"testing"
+
+	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/schema"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadClubTransferCSVWithBOM(t *testing.T) {
+	rows, err := ReadClubTransferCSV(filepath.Join("..", "..", "data", "dd_club_transfer.csv"), DefaultConfig())
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, rows)
+	assert.NotEmpty(t, rows[0].MemberID)
+}
+
+func TestParseClubTransferRecordsHeaderVariations(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+	}{
+		{
+			name: "byte order mark and surrounding whitespace",
+			headers: []string{
+				"\uFEFFMember Id", " Fob Number ", "First Name", "Last Name", "Membership Type", "Home Club", "Target Club",
+			},
+		},
+		{
+			name: "case and separators",
+			headers: []string{
+				"MEMBER ID", "fob_number", "first-name", "LastName", "membership type", "Home  Club", "target.club",
+			},
+		},
+		{
+			name: "default aliases",
+			headers: []string{
+				"MemberId", "Fob No", "Given Name", "Surname", "Plan", "Home Location", "Target Location",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseClubTransferRecords([][]string{
+				tt.headers,
+				{"12345", "FOB001", "John", "Doe", "Premium", "club a", "club b"},
+			}, DefaultConfig())
+
+			require.NoError(t, err)
+			require.Len(t, rows, 1)
+			assert.Equal(t, "12345", rows[0].MemberID)
+			assert.Equal(t, "FOB001", rows[0].FobNumber)
+			assert.Equal(t, "Doe", rows[0].LastName)
+			assert.Equal(t, "Premium", rows[0].MembershipType)
+			assert.Equal(t, "CLUB A", rows[0].HomeClub)
+			assert.Equal(t, "CLUB B", rows[0].TargetClub)
+		})
+	}
+}
+
+func TestConfigAddAlias(t *testing.T) {
+	records := [][]string{
+		{"Member Id", "Fob Number", "First Name", "Last Name", "Membership Type", "Branch", "Target Club"},
+		{"12345", "FOB001", "John", "Doe", "Premium", "CLUB A", "CLUB B"},
+	}
+
+	config := DefaultConfig()
+	_, err := ParseClubTransferRecords(records, config)
+	assert.EqualError(t, err, "column Home Club not found")
+
+	require.NoError(t, config.AddAlias("home club", "Branch"))
+	rows, err := ParseClubTransferRecords(records, config)
+	require.NoError(t, err)
+	assert.Equal(t, "CLUB A", rows[0].HomeClub)
+
+	err = config.AddAlias("Branch", "Site")
+	assert.EqualError(t, err, `unknown column "Branch", expected one of: `+
+		"Member Id, Fob Number, First Name, Last Name, Membership Type, Home Club, Target Club")
+
+	// A zero config only matches the column names themselves
+	var empty Config
+	require.NoError(t, empty.AddAlias("Home Club", "Branch"))
+	_, err = ParseClubTransferRecords(records, empty)
+	assert.NoError(t, err)
+}
+
+func TestParseClubTransferRecordsCustomColumns(t *testing.T) {
+	config := Config{Columns: schema.Columns{
+		{Name: "Member Number", Field: "MemberID", Required: true},
+		{Name: "Branch", Field: "HomeClub", Required: true, Aliases: []string{"Home Branch"}},
+		{Name: "New Branch", Field: "TargetClub", Required: true},
+		{Name: "Fob", Field: "FobNumber"},
+	}}
+
+	rows, err := ParseClubTransferRecords([][]string{
+		{"Home Branch", "Member Number", "New Branch", "Notes"},
+		{"club a", "12345", "club b", "moving house"},
+	}, config)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.ClubTransferRow{
+		{MemberID: "12345", HomeClub: "CLUB A", TargetClub: "CLUB B", Line: 2},
+	}, rows)
+	assert.Empty(t, config.ValidateRow(rows[0]))
+
+	_, err = ParseClubTransferRecords([][]string{{"Member Number", "Fob"}}, config)
+	assert.EqualError(t, err, "column Branch not found")
+
+	err = config.AddAlias("Member Id", "Member")
+	assert.EqualError(t, err, `unknown column "Member Id", expected one of: Member Number, Branch, New Branch, Fob`)
+}
@@ -0,0 +1,124 @@
+package csvutil
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+
+	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/schema"
+)
+
+// RecordFunc returns the next record of an input file and the line it
+// starts on, or io.EOF after the last record
+type RecordFunc func() (record []string, line int, err error)
+
+// ClubTransferReader reads club transfer rows one at a time, so input
+// files of any size are processed without holding every row in memory
+type ClubTransferReader struct {
+	next    RecordFunc
+	columns schema.Columns
+	colMap  map[string]int
+}
+
+// NewClubTransferReader reads the header row with next and returns a reader
+// for the rows after it. Input columns are matched by name or alias.
+func NewClubTransferReader(next RecordFunc, config Config) (*ClubTransferReader, error) {
+	header, _, err := next()
+	if err == io.EOF {
+		return nil, fmt.Errorf("no records found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	colMap, err := config.columnIndex(header)
+	if err != nil {
+		return nil, err
+	}
+	return &ClubTransferReader{next: next, columns: config.columns(), colMap: colMap}, nil
+}
+
+// NewCSVReader returns a reader for CSV content. A leading UTF-8 byte order
+// mark is skipped.
+func NewCSVReader(r io.Reader, config Config) (*ClubTransferReader, error) {
+	buffered := bufio.NewReader(r)
+	if prefix, err := buffered.Peek(len(utf8BOM)); err == nil && string(prefix) == utf8BOM {
+		_, _ = buffered.Discard(len(utf8BOM))
+	}
+
+	// Rows with missing fields are reported by row validation rather than
+	// failing the whole file
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	return NewClubTransferReader(func() ([]string, int, error) {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil, 0, err
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read file: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		return record, line, nil
+	}, config)
+}
+
+// Read returns the next row, or io.EOF after the last one. Records shorter
+// than the header, as spreadsheets produce for trailing empty cells, are
+// padded with empty fields, optional columns missing from the header are
+// left empty and empty records are skipped. Club names are upper-cased.
+func (r *ClubTransferReader) Read() (model.ClubTransferRow, error) {
+	for {
+		record, line, err := r.next()
+		if err != nil {
+			return model.ClubTransferRow{}, err
+		}
+		if len(record) == 0 {
+			continue
+		}
+
+		row := model.ClubTransferRow{Line: line}
+		for _, col := range r.columns {
+			if index, ok := r.colMap[col.Name]; ok && index < len(record) {
+				col.SetRowValue(&row, record[index])
+			}
+		}
+		row.HomeClub = strings.ToUpper(row.HomeClub)
+		row.TargetClub = strings.ToUpper(row.TargetClub)
+		return row, nil
+	}
+}
+
+// All returns an iterator over the remaining rows. Iteration stops after
+// the first error, which is yielded with a zero row.
+func (r *ClubTransferReader) All() iter.Seq2[model.ClubTransferRow, error] {
+	return func(yield func(model.ClubTransferRow, error) bool) {
+		for {
+			row, err := r.Read()
+			if err == io.EOF {
+				return
+			}
+			if !yield(row, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Collect reads the remaining rows into a slice
+func (r *ClubTransferReader) Collect() ([]model.ClubTransferRow, error) {
+	var rows []model.ClubTransferRow
+	for row, err := range r.All() {
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,135 @@
+package csvutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"coral.daniel-guo.com/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const readerHeader = "Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club\n"
+
+func TestClubTransferReaderRead(t *testing.T) {
+	reader, err := NewCSVReader(strings.NewReader(readerHeader+
+		"12345,FOB001,John,Doe,Premium,club a,CLUB B\n"+
+		"\n"+
+		"67890,FOB002,Jane,Smith,Standard,CLUB C\n"), DefaultConfig())
+	require.NoError(t, err)
+
+	row, err := reader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, model.ClubTransferRow{
+		MemberID:       "12345",
+		FobNumber:      "FOB001",
+		FirstName:      "John",
+		LastName:       "Doe",
+		MembershipType: "Premium",
+		HomeClub:       "CLUB A",
+		TargetClub:     "CLUB B",
+		Line:           2,
+	}, row)
+
+	// The blank line is skipped and the short record padded
+	row, err = reader.Read()
+	require.NoError(t, err)
+	assert.Equal(t, 4, row.Line)
+	assert.Equal(t, "", row.TargetClub)
+
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestClubTransferReaderAll(t *testing.T) {
+	var content strings.Builder
+	content.WriteString(readerHeader)
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(&content, "%d,FOB%d,First,Last,Premium,CLUB A,CLUB B\n", i, i)
+	}
+
+	t.Run("should yield every row", func(t *testing.T) {
+		reader, err := NewCSVReader(strings.NewReader(content.String()), DefaultConfig())
+		require.NoError(t, err)
+
+		var ids []string
+		for row, err := range reader.All() {
+			require.NoError(t, err)
+			ids = append(ids, row.MemberID)
+		}
+		assert.Equal(t, []string{"1", "2", "3", "4", "5"}, ids)
+	})
+
+	t.Run("should stop when the caller breaks", func(t *testing.T) {
+		reader, err := NewCSVReader(strings.NewReader(content.String()), DefaultConfig())
+		require.NoError(t, err)
+
+		for row := range reader.All() {
+			if row.MemberID == "2" {
+				break
+			}
+		}
+		row, err := reader.Read()
+		require.NoError(t, err)
+		assert.Equal(t, "3", row.MemberID)
+	})
+
+	t.Run("should stop after an error", func(t *testing.T) {
+		reader, err := NewCSVReader(strings.NewReader(readerHeader+
+			"1,FOB1,First,Last,Premium,CLUB A,CLUB B\n"+
+			"2,\"FOB2,First,Last,Premium,CLUB A,CLUB B\n"), DefaultConfig())
+		require.NoError(t, err)
+
+		var errs []error
+		count := 0
+		for _, err := range reader.All() {
+			count++
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		assert.Equal(t, 2, count)
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Error(), "failed to read file")
+	})
+}
+
+func TestNewClubTransferReader(t *testing.T) {
+	t.Run("should fail without a header row", func(t *testing.T) {
+		_, err := NewCSVReader(strings.NewReader(""), DefaultConfig())
+		assert.EqualError(t, err, "no records found")
+	})
+
+	t.Run("should pass on record errors", func(t *testing.T) {
+		failure := errors.New("disk on fire")
+		_, err := NewClubTransferReader(func() ([]string, int, error) {
+			return nil, 0, failure
+		}, DefaultConfig())
+		assert.ErrorIs(t, err, failure)
+	})
+
+	t.Run("should use the lines of the record function", func(t *testing.T) {
+		records := [][]string{
+			strings.Split(strings.TrimSpace(readerHeader), ","),
+			{"1", "FOB1", "First", "Last", "Premium", "CLUB A", "CLUB B"},
+		}
+		lines := []int{10, 12}
+		i := 0
+		reader, err := NewClubTransferReader(func() ([]string, int, error) {
+			if i == len(records) {
+				return nil, 0, io.EOF
+			}
+			i++
+			return records[i-1], lines[i-1], nil
+		}, DefaultConfig())
+		require.NoError(t, err)
+
+		rows, err := reader.Collect()
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, 12, rows[0].Line)
+	})
+}
@@ -0,0 +1,52 @@
+package csvutil
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"coral.daniel-guo.com/internal/model"
+)
+
+// Reject is an input row that failed validation
+type Reject struct {
+	Row    model.ClubTransferRow
+	Reason string
+}
+
+// WriteRejectsCSV writes rejected rows with their line and reason to a CSV
+// file. The input columns keep their names, so the file can be fixed and
+// used as input again.
+func WriteRejectsCSV(fileName string, rejects []Reject, config Config) (err error) {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to create rejects file: %w", err)
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close rejects file: %w", cerr)
+		}
+	}()
+
+	columns := config.columns()
+	writer := csv.NewWriter(file)
+	if err := writer.Write(append([]string{"Line", "Reason"}, columns.Names()...)); err != nil {
+		return fmt.Errorf("failed to write headers: %w", err)
+	}
+	for _, reject := range rejects {
+		record := []string{strconv.Itoa(reject.Row.Line), reject.Reason}
+		for _, column := range columns {
+			record = append(record, column.RowValue(reject.Row))
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error flushing csv writer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package csvutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRejectsCSV(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "rejects.csv")
+	rejects := []Reject{
+		{
+			Row:    model.ClubTransferRow{FobNumber: "FOB001", FirstName: "John", HomeClub: "CLUB A", Line: 3},
+			Reason: "missing Member Id, Last Name, Membership Type, Target Club",
+		},
+		{
+			Row: model.ClubTransferRow{
+				MemberID:       "67890",
+				FobNumber:      "FOB002",
+				FirstName:      "Jane",
+				LastName:       "Smith",
+				MembershipType: "Standard",
+				HomeClub:       "CLUB B",
+				TargetClub:     "CLUB B",
+				Line:           7,
+			},
+			Reason: "Home Club and Target Club are both CLUB B",
+		},
+	}
+
+	require.NoError(t, WriteRejectsCSV(fileName, rejects, DefaultConfig()))
+
+	content, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, "Line,Reason,Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club\n"+
+		`3,"missing Member Id, Last Name, Membership Type, Target Club",,FOB001,John,,,CLUB A,`+"\n"+
+		"7,Home Club and Target Club are both CLUB B,67890,FOB002,Jane,Smith,Standard,CLUB B,CLUB B\n",
+		string(content))
+
+	// The rejects file can be read back as input
+	rows, err := ReadClubTransferCSV(fileName, DefaultConfig())
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "67890", rows[1].MemberID)
+}
+
+func TestWriteRejectsCSVInvalidPath(t *testing.T) {
+	err := WriteRejectsCSV(filepath.Join(t.TempDir(), "missing", "rejects.csv"), nil, DefaultConfig())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create rejects file")
+}
+
+func TestWriteRejectsCSVCustomColumns(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "rejects.csv")
+	config := Config{Columns: schema.Columns{
+		{Name: "Member Number", Field: "MemberID", Required: true},
+		{Name: "Branch", Field: "HomeClub", Required: true},
+		{Name: "New Branch", Field: "TargetClub", Required: true},
+	}}
+	rejects := []Reject{{Row: model.ClubTransferRow{HomeClub: "CLUB A", TargetClub: "CLUB B", Line: 2},
+		Reason: "missing Member Number"}}
+
+	require.NoError(t, WriteRejectsCSV(fileName, rejects, config))
+
+	content, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, "Line,Reason,Member Number,Branch,New Branch\n2,missing Member Number,,CLUB A,CLUB B\n",
+		string(content))
+}
@@ -0,0 +1,49 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ses"
+)
+
+// IsRetryable reports whether a send error is transient, such as SES
+// throttling or a temporary SMTP failure. Rejected messages, invalid
+// configuration and cancelled contexts are permanent.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		switch awsErr.Code() {
+		case ses.ErrCodeMessageRejected,
+			ses.ErrCodeMailFromDomainNotVerifiedException,
+			ses.ErrCodeConfigurationSetDoesNotExistException,
+			ses.ErrCodeAccountSendingPausedException,
+			ses.ErrCodeConfigurationSetSendingPausedException:
+			return false
+		case "ServiceUnavailable", "InternalFailure", "InternalError":
+			return true
+		case "Throttling":
+			// The daily quota does not recover within a run
+			return !strings.Contains(strings.ToLower(awsErr.Message()), "daily message quota")
+		}
+		return request.IsErrorThrottle(err) || request.IsErrorRetryable(err)
+	}
+
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		// 4xx replies are transient, 5xx replies are permanent
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
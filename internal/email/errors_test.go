@@ -0,0 +1,42 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"ses throttling", awserr.New("Throttling", "Maximum sending rate exceeded.", nil), true},
+		{"ses daily quota", awserr.New("Throttling", "Daily message quota exceeded.", nil), false},
+		{"ses unavailable", awserr.New("ServiceUnavailable", "unavailable", nil), true},
+		{"ses internal failure", awserr.New("InternalFailure", "internal", nil), true},
+		{"ses message rejected", awserr.New(ses.ErrCodeMessageRejected, "Email address is not verified.", nil), false},
+		{"ses unknown", awserr.New("ValidationError", "bad input", nil), false},
+		{"wrapped throttling", fmt.Errorf("send: %w", awserr.New("Throttling", "rate", nil)), true},
+		{"smtp temporary", &textproto.Error{Code: 421, Msg: "try again later"}, true},
+		{"smtp permanent", &textproto.Error{Code: 550, Msg: "mailbox unavailable"}, false},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"cancelled", context.Canceled, false},
+		{"plain", errors.New("mail directory is not configured"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsRetryable(tt.err))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileConfig contains configuration for writing messages to local files
+type FileConfig struct {
+	// Dir is the directory messages are written to
+	Dir string
+
+	// Maildir writes messages using the maildir layout (tmp/new/cur)
+	// instead of plain .eml files
+	Maildir bool
+}
+
+// FileTransport writes each message to the local filesystem instead of sending it
+type FileTransport struct {
+	config  FileConfig
+	counter atomic.Uint64
+}
+
+// NewFileTransport creates a new file transport with the given configuration
+func NewFileTransport(config FileConfig) *FileTransport {
+	return &FileTransport{config: config}
+}
+
+// Send writes the message to a new file and returns its path
+func (t *FileTransport) Send(ctx context.Context, _ string, _ []string, message []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if t.config.Dir == "" {
+		return "", fmt.Errorf("mail directory is not configured")
+	}
+
+	name := t.uniqueName()
+
+	if !t.config.Maildir {
+		if err := os.MkdirAll(t.config.Dir, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create mail directory: %w", err)
+		}
+		path := filepath.Join(t.config.Dir, name+".eml")
+		if err := os.WriteFile(path, message, 0o644); err != nil {
+			return "", fmt.Errorf("failed to write message: %w", err)
+		}
+		return path, nil
+	}
+
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(t.config.Dir, sub), 0o755); err != nil {
+			return "", fmt.Errorf("failed to create maildir: %w", err)
+		}
+	}
+
+	// Write to tmp first and move into new so readers never see partial messages
+	tmpPath := filepath.Join(t.config.Dir, "tmp", name)
+	newPath := filepath.Join(t.config.Dir, "new", name)
+	if err := os.WriteFile(tmpPath, message, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := os.Rename(tmpPath, newPath); err != nil {
+		return "", fmt.Errorf("failed to deliver message: %w", err)
+	}
+	return newPath, nil
+}
+
+// uniqueName returns a file name that is unique for this process
+func (t *FileTransport) uniqueName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%d.%d_%d.%s", time.Now().UnixNano(), os.Getpid(), t.counter.Add(1), host)
+}
@@ -0,0 +1,64 @@
+package email
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTransport_Send(t *testing.T) {
+	t.Run("should write eml files", func(t *testing.T) {
+		dir := t.TempDir()
+		transport := NewFileTransport(FileConfig{Dir: dir})
+
+		first, err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, []byte("first"))
+		require.NoError(t, err)
+		second, err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, []byte("second"))
+		require.NoError(t, err)
+
+		assert.NotEqual(t, first, second)
+		assert.Equal(t, ".eml", filepath.Ext(first))
+
+		content, err := os.ReadFile(first)
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(content))
+	})
+
+	t.Run("should deliver into maildir new folder", func(t *testing.T) {
+		dir := t.TempDir()
+		transport := NewFileTransport(FileConfig{Dir: dir, Maildir: true})
+
+		path, err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, []byte("message"))
+		require.NoError(t, err)
+
+		assert.Equal(t, filepath.Join(dir, "new"), filepath.Dir(path))
+		for _, sub := range []string{"tmp", "new", "cur"} {
+			assert.DirExists(t, filepath.Join(dir, sub))
+		}
+		entries, err := os.ReadDir(filepath.Join(dir, "tmp"))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("should fail without directory", func(t *testing.T) {
+		transport := NewFileTransport(FileConfig{})
+
+		_, err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, []byte("message"))
+
+		assert.EqualError(t, err, "mail directory is not configured")
+	})
+}
+
+func TestFileTransport_SendCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	transport := NewFileTransport(FileConfig{Dir: t.TempDir()})
+
+	_, err := transport.Send(ctx, "from@example.com", []string{"to@example.com"}, []byte("message"))
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,373 @@
+package email
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLToText renders an HTML document as readable plain text for the
+// text/plain alternative of an email. Paragraphs and headings are separated
+// by blank lines, lists are bulleted or numbered, links keep their target,
+// tables are laid out in aligned columns and entities are decoded.
+func HTMLToText(body string) string {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return body
+	}
+
+	w := &textWriter{}
+	w.walkChildren(doc)
+	return w.String()
+}
+
+// textWriter accumulates plain text while collapsing whitespace the way a browser does
+type textWriter struct {
+	buf strings.Builder
+
+	// pending is the number of line breaks to write before the next text
+	pending int
+	// space records collapsed whitespace to write before the next word
+	space bool
+	// lineStart is true when nothing has been written on the current line
+	lineStart bool
+	// prefixes are written at the start of every line (list indentation, quotes)
+	prefixes []string
+	// pre counts the enclosing <pre> elements, where whitespace is kept
+	pre int
+}
+
+// blockSpacing is the number of line breaks around block elements;
+// 2 leaves a blank line, 1 starts a new line
+var blockSpacing = map[atom.Atom]int{
+	atom.P:          2,
+	atom.H1:         2,
+	atom.H2:         2,
+	atom.H3:         2,
+	atom.H4:         2,
+	atom.H5:         2,
+	atom.H6:         2,
+	atom.Blockquote: 2,
+	atom.Pre:        2,
+	atom.Table:      2,
+	atom.Ul:         2,
+	atom.Ol:         2,
+	atom.Dl:         2,
+	atom.Div:        1,
+	atom.Section:    1,
+	atom.Article:    1,
+	atom.Header:     1,
+	atom.Footer:     1,
+	atom.Main:       1,
+	atom.Nav:        1,
+	atom.Aside:      1,
+	atom.Address:    1,
+	atom.Figure:     1,
+	atom.Form:       1,
+	atom.Dt:         1,
+	atom.Dd:         1,
+	atom.Tr:         1,
+}
+
+// skipped elements have no visible text
+var skipped = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Template: true,
+	atom.Noscript: true,
+}
+
+func (w *textWriter) walkChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+func (w *textWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		w.walkChildren(n)
+		return
+	}
+
+	if skipped[n.DataAtom] {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		w.pending++
+		w.space = false
+		return
+	case atom.Hr:
+		w.block(2)
+		w.word("----------------------------------------")
+		w.block(2)
+		return
+	case atom.Img:
+		if alt := attr(n, "alt"); alt != "" {
+			w.text(alt)
+		}
+		return
+	case atom.A:
+		w.link(n)
+		return
+	case atom.Li:
+		w.listItem(n)
+		return
+	case atom.Table:
+		w.block(2)
+		w.table(n)
+		w.block(2)
+		return
+	case atom.Blockquote:
+		w.block(2)
+		w.prefixes = append(w.prefixes, "> ")
+		w.walkChildren(n)
+		w.prefixes = w.prefixes[:len(w.prefixes)-1]
+		w.block(2)
+		return
+	case atom.Pre:
+		w.block(2)
+		w.pre++
+		w.walkChildren(n)
+		w.pre--
+		w.block(2)
+		return
+	case atom.Ul, atom.Ol:
+		// Nested lists start on the next line of their item
+		if w.inList() {
+			w.block(1)
+			w.walkChildren(n)
+			w.block(1)
+			return
+		}
+	}
+
+	spacing, isBlock := blockSpacing[n.DataAtom]
+	if !isBlock {
+		w.walkChildren(n)
+		return
+	}
+	w.block(spacing)
+	w.walkChildren(n)
+	w.block(spacing)
+}
+
+// link writes the link text followed by its target when it adds information
+func (w *textWriter) link(n *html.Node) {
+	text := renderInline(n)
+	href := strings.TrimSpace(attr(n, "href"))
+	target := strings.TrimPrefix(href, "mailto:")
+
+	w.text(text)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return
+	}
+	if strings.TrimSpace(text) == target {
+		return
+	}
+	if strings.TrimSpace(text) == "" {
+		w.text(target)
+		return
+	}
+	w.text(" (" + target + ")")
+}
+
+// listItem writes a bulleted or numbered list item with hanging indentation
+func (w *textWriter) listItem(n *html.Node) {
+	marker := "* "
+	if parent := n.Parent; parent != nil && parent.DataAtom == atom.Ol {
+		index := 1
+		if start, err := strconv.Atoi(attr(parent, "start")); err == nil {
+			index = start
+		}
+		for s := parent.FirstChild; s != nil && s != n; s = s.NextSibling {
+			if s.Type == html.ElementNode && s.DataAtom == atom.Li {
+				index++
+			}
+		}
+		marker = strconv.Itoa(index) + ". "
+	}
+
+	w.block(1)
+	w.word(marker)
+	w.space = false
+	w.prefixes = append(w.prefixes, strings.Repeat(" ", utf8.RuneCountInString(marker)))
+	w.walkChildren(n)
+	w.prefixes = w.prefixes[:len(w.prefixes)-1]
+	w.block(1)
+}
+
+// table writes the rows of a table as aligned columns, underlining a header row
+func (w *textWriter) table(n *html.Node) {
+	var rows [][]string
+	header := -1
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Tr:
+				var cells []string
+				allHeadings := true
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+						continue
+					}
+					allHeadings = allHeadings && cell.DataAtom == atom.Th
+					cells = append(cells, renderInline(cell))
+				}
+				if len(cells) == 0 {
+					continue
+				}
+				if allHeadings && header < 0 && len(rows) == 0 {
+					header = 0
+				}
+				rows = append(rows, cells)
+			case atom.Table:
+				// Nested tables are flattened into the cell text
+			default:
+				collect(c)
+			}
+		}
+	}
+	collect(n)
+
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	for r, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			line.WriteString(cell)
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+			}
+		}
+		w.block(1)
+		w.word(strings.TrimRight(line.String(), " "))
+		if r == header {
+			total := 0
+			for _, width := range widths {
+				total += width
+			}
+			w.block(1)
+			w.word(strings.Repeat("-", total+2*(len(widths)-1)))
+		}
+	}
+}
+
+// text writes character data, collapsing whitespace outside <pre>
+func (w *textWriter) text(s string) {
+	if w.pre > 0 {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				w.pending++
+			}
+			if line != "" {
+				w.word(line)
+			}
+		}
+		return
+	}
+
+	if s != "" && isSpace(s[0]) {
+		w.space = true
+	}
+	for _, field := range strings.Fields(s) {
+		w.word(field)
+		w.space = true
+	}
+	if s != "" && !isSpace(s[len(s)-1]) {
+		w.space = false
+	}
+}
+
+// word writes s after any pending line breaks or collapsed whitespace
+func (w *textWriter) word(s string) {
+	if w.buf.Len() == 0 {
+		w.pending = 0
+		w.lineStart = true
+	}
+	if w.pending > 0 {
+		w.buf.WriteString(strings.Repeat("\n", w.pending))
+		w.pending = 0
+		w.lineStart = true
+	}
+	if w.lineStart {
+		for _, prefix := range w.prefixes {
+			w.buf.WriteString(prefix)
+		}
+		w.lineStart = false
+	} else if w.space {
+		w.buf.WriteByte(' ')
+	}
+	w.space = false
+	w.buf.WriteString(s)
+}
+
+// block requests at least n line breaks before the next text
+func (w *textWriter) block(n int) {
+	if n > w.pending {
+		w.pending = n
+	}
+	w.space = false
+}
+
+// inList reports whether the writer is inside a list item
+func (w *textWriter) inList() bool {
+	for _, prefix := range w.prefixes {
+		if strings.TrimSpace(prefix) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the text with trailing whitespace removed from every line
+func (w *textWriter) String() string {
+	lines := strings.Split(w.buf.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// renderInline renders the children of n as a single line of text
+func renderInline(n *html.Node) string {
+	w := &textWriter{}
+	w.walkChildren(n)
+	return strings.Join(strings.Fields(w.String()), " ")
+}
+
+// attr returns the value of the named attribute of n
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
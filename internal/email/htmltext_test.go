@@ -0,0 +1,113 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "inline elements",
+			input:    "<p>Hello <b>World</b></p>",
+			expected: "Hello World",
+		},
+		{
+			name: "paragraphs",
+			input: `<html><head><title>Ignored</title><style>p { color: red; }</style></head>
+<body>
+    <p>Hello team,</p>
+    <p>Please find attached
+       the transfer data.</p>
+    <p>Regards</p>
+</body></html>`,
+			expected: "Hello team,\n\nPlease find attached the transfer data.\n\nRegards",
+		},
+		{
+			name:     "plain text",
+			input:    "Plain text message",
+			expected: "Plain text message",
+		},
+		{
+			name:     "empty",
+			input:    "",
+			expected: "",
+		},
+		{
+			name:     "attributes",
+			input:    `<div class="container"><span style="color: red;">Red text</span></div>`,
+			expected: "Red text",
+		},
+		{
+			name:     "entities and angle brackets",
+			input:    "<p>Smith &amp; Sons &lt;3 &quot;gym&quot; &#8212; 5 &gt; 3</p>",
+			expected: "Smith & Sons <3 \"gym\" — 5 > 3",
+		},
+		{
+			name:     "line breaks",
+			input:    "<p>Line one<br>Line two<br/><br/>Line four</p>",
+			expected: "Line one\nLine two\n\nLine four",
+		},
+		{
+			name:     "unordered list",
+			input:    "<p>Clubs:</p><ul><li>CLUB A</li><li>CLUB <i>B</i></li></ul><p>Thanks</p>",
+			expected: "Clubs:\n\n* CLUB A\n* CLUB B\n\nThanks",
+		},
+		{
+			name:     "ordered and nested list",
+			input:    `<ol start="3"><li>First<ul><li>Nested</li></ul></li><li>Second</li></ol>`,
+			expected: "3. First\n   * Nested\n4. Second",
+		},
+		{
+			name: "links",
+			input: `<p>See <a href="https://example.com/help">the guide</a> or ` +
+				`<a href="mailto:help@example.com">help@example.com</a>.</p>`,
+			expected: "See the guide (https://example.com/help) or help@example.com.",
+		},
+		{
+			name:     "anchor links",
+			input:    `<p><a href="#top">Back to top</a></p>`,
+			expected: "Back to top",
+		},
+		{
+			name: "table",
+			input: `<table>
+<tr><th>Club</th><th>Members</th></tr>
+<tr><td>CLUB A</td><td>12</td></tr>
+<tr><td>LONG CLUB NAME</td><td>3</td></tr>
+</table>`,
+			expected: "Club            Members\n-----------------------\nCLUB A          12\nLONG CLUB NAME  3",
+		},
+		{
+			name:     "headings and rules",
+			input:    "<h1>Title</h1>Intro<hr><h2>Section</h2>",
+			expected: "Title\n\nIntro\n\n----------------------------------------\n\nSection",
+		},
+		{
+			name:     "blockquote",
+			input:    "<p>They wrote:</p><blockquote><p>First</p><p>Second</p></blockquote>",
+			expected: "They wrote:\n\n> First\n\n> Second",
+		},
+		{
+			name:     "preformatted",
+			input:    "<pre>a  b\n  c</pre>",
+			expected: "a  b\n  c",
+		},
+		{
+			name:     "images",
+			input:    `<p><img src="logo.png" alt="The Hub"> news</p>`,
+			expected: "The Hub news",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, HTMLToText(tt.input))
+		})
+	}
+}
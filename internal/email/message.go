@@ -0,0 +1,316 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxLineLength is the line length limit recommended by RFC 5322
+const maxLineLength = 76
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	Name string
+
+	// ContentType is the media type, e.g. application/pdf; derived from the
+	// extension of Name when empty
+	ContentType string
+
+	Content []byte
+}
+
+// ReadAttachment reads a file into an attachment named after the file
+func ReadAttachment(path string) (Attachment, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	return Attachment{Name: filepath.Base(path), Content: content}, nil
+}
+
+// mediaType returns the attachment's media type without parameters
+func (a Attachment) mediaType() string {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(a.Name))
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+// Message is an email with an HTML body and any number of attachments
+type Message struct {
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo []string
+	Subject string
+
+	// HTMLBody is sent as the HTML alternative and rendered as text for the plain alternative
+	HTMLBody string
+
+	Attachments []Attachment
+}
+
+// Recipients returns the envelope recipients of the message (To, Cc and
+// Bcc) without duplicates
+func (m *Message) Recipients() []string {
+	seen := make(map[string]bool)
+	var recipients []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, address := range list {
+			key := strings.ToLower(envelopeAddress(address))
+			if !seen[key] {
+				seen[key] = true
+				recipients = append(recipients, envelopeAddress(address))
+			}
+		}
+	}
+	return recipients
+}
+
+// buildMessage renders a multipart/mixed MIME message with a text and HTML
+// alternative followed by the attachments. Headers are RFC 2047 encoded and
+// folded, attachment filenames are RFC 2231 encoded, bodies are
+// quoted-printable and attachments are base64 with wrapped lines, so the
+// output never exceeds the SMTP line length limits. Bcc recipients are
+// never written to the headers.
+func (s *Sender) buildMessage(msg *Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	mixed := multipart.NewWriter(&buf)
+	if err := s.setBoundary(mixed); err != nil {
+		return nil, err
+	}
+
+	writeHeader(&buf, "From", formatAddress(msg.From))
+	writeHeader(&buf, "To", formatAddressList(msg.To))
+	if len(msg.Cc) > 0 {
+		writeHeader(&buf, "Cc", formatAddressList(msg.Cc))
+	}
+	if len(msg.ReplyTo) > 0 {
+		writeHeader(&buf, "Reply-To", formatAddressList(msg.ReplyTo))
+	}
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	writeHeader(&buf, "Date", s.now().Format(time.RFC1123Z))
+	writeHeader(&buf, "MIME-Version", "1.0")
+	writeHeader(&buf, "Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{
+		"boundary": mixed.Boundary(),
+	}))
+	buf.WriteString("\r\n")
+
+	// Text and HTML versions of the body
+	var alternative bytes.Buffer
+	alt := multipart.NewWriter(&alternative)
+	if err := s.setBoundary(alt); err != nil {
+		return nil, err
+	}
+	if err := writeQuotedPrintablePart(alt, "text/plain", HTMLToText(msg.HTMLBody)); err != nil {
+		return nil, err
+	}
+	if err := writeQuotedPrintablePart(alt, "text/html", msg.HTMLBody); err != nil {
+		return nil, err
+	}
+	if err := alt.Close(); err != nil {
+		return nil, err
+	}
+
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {formatMediaType("multipart/alternative", "boundary", alt.Boundary())},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(alternative.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range msg.Attachments {
+		if err := writeAttachment(mixed, attachment); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeAttachment writes a base64 encoded attachment part
+func writeAttachment(w *multipart.Writer, attachment Attachment) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {formatMediaType(attachment.mediaType(), "name", attachment.Name)},
+		"Content-Disposition":       {formatMediaType("attachment", "filename", attachment.Name)},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeBase64(part, attachment.Content); err != nil {
+		return fmt.Errorf("failed to encode attachment %s: %w", attachment.Name, err)
+	}
+	return nil
+}
+
+// setBoundary applies the sender's boundary generator, if any
+func (s *Sender) setBoundary(w *multipart.Writer) error {
+	if s.boundary == nil {
+		return nil
+	}
+	return w.SetBoundary(s.boundary())
+}
+
+// formatAddress returns the address with its display name RFC 2047 encoded.
+// Addresses that cannot be parsed are returned unchanged.
+func formatAddress(address string) string {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return address
+	}
+	if parsed.Name == "" {
+		return parsed.Address
+	}
+	return parsed.String()
+}
+
+// formatAddressList formats addresses for an address list header
+func formatAddressList(addresses []string) string {
+	formatted := make([]string, len(addresses))
+	for i, address := range addresses {
+		formatted[i] = formatAddress(address)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// envelopeAddress returns the bare address used in the SMTP envelope
+func envelopeAddress(address string) string {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return address
+	}
+	return parsed.Address
+}
+
+// writeHeader writes a header field, folding it at spaces so no line
+// exceeds the recommended length. A long first word, such as an RFC 2047
+// encoded word, starts on a continuation line.
+func writeHeader(w *bytes.Buffer, key, value string) {
+	line := key + ":"
+	for _, word := range strings.Split(value, " ") {
+		if len(line)+1+len(word) > maxLineLength {
+			w.WriteString(line)
+			w.WriteString("\r\n")
+			line = ""
+		}
+		line += " " + word
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// formatMediaType formats a media type with a single parameter. Non-ASCII
+// values use RFC 2231 encoding, and values too long for one line are split
+// into RFC 2231 continuations on their own lines.
+func formatMediaType(mediaType, param, value string) string {
+	formatted := mime.FormatMediaType(mediaType, map[string]string{param: value})
+	if formatted == "" {
+		return mediaType
+	}
+	if len(formatted) <= maxLineLength-len("Content-Disposition: ") {
+		return formatted
+	}
+	if !strings.Contains(formatted, param+"*=") && len(formatted)-len(mediaType) < maxLineLength {
+		return strings.Replace(formatted, "; ", ";\r\n ", 1)
+	}
+
+	var b strings.Builder
+	b.WriteString(mediaType)
+	for i, chunk := range splitEncoded("utf-8''"+percentEncode(value), maxLineLength-len(param)-8) {
+		fmt.Fprintf(&b, ";\r\n %s*%d*=%s", param, i, chunk)
+	}
+	return b.String()
+}
+
+// percentEncode encodes a parameter value as an RFC 2231 extended value
+func percentEncode(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if isAttributeChar(c) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// isAttributeChar reports whether c may appear unencoded in an RFC 2231 value
+func isAttributeChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
+
+// splitEncoded splits a percent-encoded value into chunks of at most size
+// bytes without breaking an escape sequence
+func splitEncoded(value string, size int) []string {
+	var chunks []string
+	for len(value) > size {
+		cut := size
+		if i := strings.LastIndexByte(value[cut-2:cut], '%'); i >= 0 {
+			cut = cut - 2 + i
+		}
+		chunks = append(chunks, value[:cut])
+		value = value[cut:]
+	}
+	return append(chunks, value)
+}
+
+// writeQuotedPrintablePart writes a UTF-8 text part with quoted-printable encoding
+func writeQuotedPrintablePart(w *multipart.Writer, mediaType, content string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(mediaType, map[string]string{"charset": "UTF-8"})},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err := io.WriteString(qp, content); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeBase64 writes data as base64 wrapped at the recommended line length
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > maxLineLength {
+		if _, err := io.WriteString(w, encoded[:maxLineLength]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[maxLineLength:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
@@ -0,0 +1,235 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+type messageCase struct {
+	name        string
+	sender      string
+	to          []string
+	cc          []string
+	bcc         []string
+	replyTo     []string
+	subject     string
+	body        string
+	attachments []Attachment
+}
+
+var messageCases = []messageCase{
+	{
+		name:    "ascii",
+		sender:  "no-reply@the-hub.ai",
+		to:      []string{"cluba@example.com"},
+		subject: "Club Transfer for Paid in Full Members (May 2025)",
+		body:    "<html><body><p>Hello team,</p><p>Regards</p></body></html>",
+		attachments: []Attachment{
+			{Name: "pif_club_transfer_CLUB A.csv", Content: []byte("Member Id,Fob Number\n12345,FOB001\n")},
+		},
+	},
+	{
+		name:    "non_ascii",
+		sender:  "The Hub Café <no-reply@the-hub.ai>",
+		to:      []string{"St John's Gym <stjohns@example.com>"},
+		subject: "Club Transfer for Direct Debit Members – Zürich & St John's Café (March - May 2025)",
+		body: "<p>Bonjour l'équipe de Zürich,</p><p>" + strings.Repeat("Ceci est une très longue ligne. ", 8) +
+			"</p><p>Price: 5 = five</p>",
+		attachments: []Attachment{
+			{
+				Name:    "dd_club_transfer_St John's Café – Zürich.csv",
+				Content: bytes.Repeat([]byte("Member Id,Fob Number,First Name\n12345,FOB001,Zoë\n"), 4),
+			},
+		},
+	},
+	{
+		name:    "recipients",
+		sender:  "no-reply@the-hub.ai",
+		to:      []string{"manager@example.com", "Front Desk <frontdesk@example.com>"},
+		cc:      []string{"owner@example.com"},
+		bcc:     []string{"headoffice@example.com"},
+		replyTo: []string{"Member Services <members@the-hub.ai>"},
+		subject: "Club Transfer for Paid in Full Members (May 2025)",
+		body:    "<p>Hello team,</p>",
+		attachments: []Attachment{
+			{Name: "pif_club_transfer_CLUB A.csv", Content: []byte("Member Id\n12345\n")},
+		},
+	},
+	{
+		name:    "attachments",
+		sender:  "no-reply@the-hub.ai",
+		to:      []string{"cluba@example.com"},
+		subject: "Club Transfers (May 2025)",
+		body:    "<p>Hello team,</p>",
+		attachments: []Attachment{
+			{Name: "pif_club_transfer_CLUB A.csv", Content: []byte("Member Id\n12345\n")},
+			{Name: "dd_club_transfer_CLUB A.csv", Content: []byte("Member Id\n67890\n")},
+			{Name: "summary", ContentType: "application/pdf", Content: []byte("%PDF-1.4\n%%EOF\n")},
+		},
+	},
+}
+
+func (tc messageCase) message() *Message {
+	return &Message{
+		From:        tc.sender,
+		To:          tc.to,
+		Cc:          tc.cc,
+		Bcc:         tc.bcc,
+		ReplyTo:     tc.replyTo,
+		Subject:     tc.subject,
+		HTMLBody:    tc.body,
+		Attachments: tc.attachments,
+	}
+}
+
+// goldenSender returns a sender producing deterministic boundaries and dates
+func goldenSender() *Sender {
+	sender := NewSenderWithTransport(DefaultConfig(), &recordingTransport{})
+	count := 0
+	sender.boundary = func() string {
+		count++
+		return fmt.Sprintf("boundary-%d", count)
+	}
+	sender.now = func() time.Time {
+		return time.Date(2025, 6, 2, 9, 30, 0, 0, time.UTC)
+	}
+	return sender
+}
+
+func TestBuildMessage_Golden(t *testing.T) {
+	for _, tc := range messageCases {
+		t.Run(tc.name, func(t *testing.T) {
+			message, err := goldenSender().buildMessage(tc.message())
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", tc.name+".eml")
+			if *update {
+				require.NoError(t, os.MkdirAll("testdata", 0o755))
+				require.NoError(t, os.WriteFile(golden, message, 0o644))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(message))
+		})
+	}
+}
+
+func TestBuildMessage_LineLength(t *testing.T) {
+	for _, tc := range messageCases {
+		message, err := goldenSender().buildMessage(tc.message())
+		require.NoError(t, err)
+
+		for i, line := range strings.Split(string(message), "\r\n") {
+			assert.LessOrEqual(t, len(line), 78, "%s: line %d is too long: %q", tc.name, i+1, line)
+			assert.NotContains(t, line, "\n", "%s: line %d has a bare line feed", tc.name, i+1)
+		}
+	}
+}
+
+func TestBuildMessage_RoundTrip(t *testing.T) {
+	for _, tc := range messageCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := goldenSender().buildMessage(tc.message())
+			require.NoError(t, err)
+
+			msg, err := mail.ReadMessage(bytes.NewReader(raw))
+			require.NoError(t, err)
+
+			decoder := new(mime.WordDecoder)
+			subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.subject, subject)
+
+			from, err := msg.Header.AddressList("From")
+			require.NoError(t, err)
+			expectedFrom, _ := mail.ParseAddress(tc.sender)
+			assert.Equal(t, expectedFrom, from[0])
+			assert.Empty(t, msg.Header.Get("Bcc"))
+			for header, expected := range map[string][]string{"To": tc.to, "Cc": tc.cc, "Reply-To": tc.replyTo} {
+				if len(expected) == 0 {
+					assert.Empty(t, msg.Header.Get(header))
+					continue
+				}
+				addresses, err := msg.Header.AddressList(header)
+				require.NoError(t, err)
+				assert.Len(t, addresses, len(expected))
+			}
+
+			_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			require.NoError(t, err)
+			mixed := multipart.NewReader(msg.Body, params["boundary"])
+
+			alternative, err := mixed.NextPart()
+			require.NoError(t, err)
+			_, altParams, err := mime.ParseMediaType(alternative.Header.Get("Content-Type"))
+			require.NoError(t, err)
+			alt := multipart.NewReader(alternative, altParams["boundary"])
+
+			textPart, err := alt.NextRawPart()
+			require.NoError(t, err)
+			text, err := io.ReadAll(quotedprintable.NewReader(textPart))
+			require.NoError(t, err)
+			assert.Equal(t, strings.ReplaceAll(HTMLToText(tc.body), "\n", "\r\n"), string(text))
+
+			htmlPart, err := alt.NextRawPart()
+			require.NoError(t, err)
+			html, err := io.ReadAll(quotedprintable.NewReader(htmlPart))
+			require.NoError(t, err)
+			assert.Equal(t, tc.body, string(html))
+
+			for _, expected := range tc.attachments {
+				attachment, err := mixed.NextPart()
+				require.NoError(t, err)
+				assert.Equal(t, expected.Name, attachment.FileName())
+				mediaType, _, err := mime.ParseMediaType(attachment.Header.Get("Content-Type"))
+				require.NoError(t, err)
+				assert.Equal(t, expected.mediaType(), mediaType)
+				assert.Equal(t, "base64", attachment.Header.Get("Content-Transfer-Encoding"))
+				content, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, attachment))
+				require.NoError(t, err)
+				assert.Equal(t, expected.Content, content)
+			}
+			_, err = mixed.NextPart()
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestMessage_Recipients(t *testing.T) {
+	msg := &Message{
+		To:  []string{"Manager <manager@example.com>", "frontdesk@example.com"},
+		Cc:  []string{"MANAGER@example.com", "owner@example.com"},
+		Bcc: []string{"headoffice@example.com"},
+	}
+
+	assert.Equal(t, []string{
+		"manager@example.com",
+		"frontdesk@example.com",
+		"owner@example.com",
+		"headoffice@example.com",
+	}, msg.Recipients())
+}
+
+func TestAttachment_MediaType(t *testing.T) {
+	assert.Equal(t, "text/csv", Attachment{Name: "transfers.csv"}.mediaType())
+	assert.Equal(t, "application/pdf", Attachment{Name: "summary", ContentType: "application/pdf"}.mediaType())
+	assert.Equal(t, "text/plain", Attachment{Name: "a.csv", ContentType: "text/plain; charset=utf-8"}.mediaType())
+	assert.Equal(t, "application/octet-stream", Attachment{Name: "data"}.mediaType())
+}
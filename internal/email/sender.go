@@ -2,178 +2,181 @@
 package email
 
 import (
-	"bytes"
-	"encoding/base64"
+	"context"
 	"fmt"
-	"mime"
-	"mime/multipart"
-	"net/textproto"
-	"os"
-	"path/filepath"
+	"io"
+	"strings"
+	"time"
 
 	"coral.daniel-guo.com/internal/logger"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/ses"
 )
 
 // Config contains email sender configuration
 type Config struct {
+	// Region is the AWS region used by the SES transport
 	Region string
+
+	// Transport selects how messages are delivered (ses, smtp or file)
+	Transport string
+
+	// SMTP configures the smtp transport
+	SMTP SMTPConfig
+
+	// File configures the file transport
+	File FileConfig
+
+	// ReplyTo is set as the Reply-To of every message that does not set its own
+	ReplyTo []string
+
+	// Bcc receives a blind copy of every message, e.g. head office
+	Bcc []string
 }
 
 // DefaultConfig returns a default email configuration
 func DefaultConfig() Config {
 	return Config{
-		Region: "ap-southeast-2",
+		Region:    "ap-southeast-2",
+		Transport: TransportSES,
 	}
 }
 
-// Sender handles sending emails via AWS SES
+// Sender builds MIME messages and delivers them through a Transport
 type Sender struct {
-	config Config
+	config    Config
+	transport Transport
+
+	// boundary generates multipart boundaries; random when nil
+	boundary func() string
+	// now returns the time used for the Date header
+	now func() time.Time
 }
 
-// NewSender creates a new email sender with the given configuration
+// NewSender creates a new email sender with the transport selected by the configuration
 func NewSender(config Config) *Sender {
-	return &Sender{
-		config: config,
+	transport, err := NewTransport(config)
+	if err != nil {
+		transport = &unsupportedTransport{err: err}
 	}
+	return NewSenderWithTransport(config, transport)
 }
 
-// SendWithAttachmentFile sends an email with an attachment from a file
-func (s *Sender) SendWithAttachmentFile(sender, recipient, subject, body, attachmentPath string) error {
-	// Read the file content
-	fileContent, err := os.ReadFile(attachmentPath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+// NewSenderWithTransport creates a new email sender that delivers through the given transport
+func NewSenderWithTransport(config Config, transport Transport) *Sender {
+	return &Sender{
+		config:    config,
+		transport: transport,
+		now:       time.Now,
 	}
-
-	// Extract filename from path
-	filename := filepath.Base(attachmentPath)
-
-	return s.SendWithAttachment(sender, recipient, subject, body, filename, fileContent)
 }
 
-// SendWithAttachment sends an email with an in-memory attachment
-func (s *Sender) SendWithAttachment(
-	sender, recipient, subject, body, attachmentName string,
-	attachmentContent []byte,
-) error {
-	// Create a buffer for the message
-	var buf bytes.Buffer
-
-	// Set up email headers
-	headers := textproto.MIMEHeader{}
-	headers.Set("From", sender)
-	headers.Set("To", recipient)
-	headers.Set("Subject", subject)
-	headers.Set("MIME-Version", "1.0")
-
-	// Create multipart writer
-	writer := multipart.NewWriter(&buf)
-	headers.Set("Content-Type", "multipart/mixed; boundary="+writer.Boundary())
-
-	// Write headers
-	for k, vv := range headers {
-		for _, v := range vv {
-			fmt.Fprintf(&buf, "%s: %s\r\n", k, v)
-		}
+// Err returns the error creating the configured transport, if any. A sender
+// whose transport could not be created fails every message.
+func (s *Sender) Err() error {
+	if unsupported, ok := s.transport.(*unsupportedTransport); ok {
+		return unsupported.err
 	}
-	buf.WriteString("\r\n")
-
-	// Create alternative part for text/html versions
-	altWriter := multipart.NewWriter(&buf)
-	fmt.Fprintf(&buf, "--%s\r\n", writer.Boundary())
-	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", altWriter.Boundary())
-
-	// Add text part
-	fmt.Fprintf(&buf, "--%s\r\n", altWriter.Boundary())
-	fmt.Fprintf(&buf, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
-	buf.WriteString(StripHTML(body))
-	buf.WriteString("\r\n")
-
-	// Add HTML part
-	fmt.Fprintf(&buf, "--%s\r\n", altWriter.Boundary())
-	fmt.Fprintf(&buf, "Content-Type: text/html; charset=UTF-8\r\n\r\n")
-	buf.WriteString(body)
-	buf.WriteString("\r\n")
-
-	// Close alternative part
-	fmt.Fprintf(&buf, "--%s--\r\n", altWriter.Boundary())
-
-	// Add attachment
-	fileExt := filepath.Ext(attachmentName)
-	mimeType := mime.TypeByExtension(fileExt)
-	if mimeType == "" {
-		mimeType = "application/octet-stream"
+	return nil
+}
+
+// Close releases resources held by the transport, such as open SMTP sessions
+func (s *Sender) Close() error {
+	if closer, ok := s.transport.(io.Closer); ok {
+		return closer.Close()
 	}
+	return nil
+}
 
-	fmt.Fprintf(&buf, "--%s\r\n", writer.Boundary())
-	fmt.Fprintf(&buf, "Content-Type: %s\r\n", mimeType)
-	fmt.Fprintf(&buf, "Content-Disposition: attachment; filename=%s\r\n", attachmentName)
-	fmt.Fprintf(&buf, "Content-Transfer-Encoding: base64\r\n\r\n")
+// RateLimited reports whether the transport enforces a maximum sending rate
+func (s *Sender) RateLimited() bool {
+	_, ok := s.transport.(RateLimitedTransport)
+	return ok
+}
 
-	// Base64 encode the attachment
-	encoder := base64.NewEncoder(base64.StdEncoding, &buf)
-	_, err := encoder.Write(attachmentContent)
-	if err != nil {
-		return fmt.Errorf("failed to encode attachment: %w", err)
+// MaxSendRate returns the maximum messages per second allowed by the
+// transport, or 0 when the transport does not enforce a rate
+func (s *Sender) MaxSendRate(ctx context.Context) (float64, error) {
+	limited, ok := s.transport.(RateLimitedTransport)
+	if !ok {
+		return 0, nil
 	}
-	if err := encoder.Close(); err != nil {
-		return fmt.Errorf("failed to close encoder: %w", err)
+	return limited.MaxSendRate(ctx)
+}
+
+// SendWithAttachmentFile sends an email with the given files attached
+func (s *Sender) SendWithAttachmentFile(
+	ctx context.Context,
+	sender, recipient, subject, body string,
+	attachmentPaths ...string,
+) (string, error) {
+	attachments := make([]Attachment, 0, len(attachmentPaths))
+	for _, path := range attachmentPaths {
+		attachment, err := ReadAttachment(path)
+		if err != nil {
+			return "", err
+		}
+		attachments = append(attachments, attachment)
 	}
-	buf.WriteString("\r\n")
 
-	// Close the multipart message
-	fmt.Fprintf(&buf, "--%s--\r\n", writer.Boundary())
+	return s.SendWithAttachments(ctx, sender, recipient, subject, body, attachments...)
+}
 
-	// Create a new AWS SES session
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(s.config.Region),
+// SendWithAttachment sends an email with an in-memory attachment to a
+// single recipient and returns the message ID reported by the transport
+func (s *Sender) SendWithAttachment(
+	ctx context.Context,
+	sender, recipient, subject, body, attachmentName string,
+	attachmentContent []byte,
+) (string, error) {
+	return s.SendWithAttachments(ctx, sender, recipient, subject, body, Attachment{
+		Name:    attachmentName,
+		Content: attachmentContent,
 	})
-	if err != nil {
-		return fmt.Errorf("failed to create SES session: %w", err)
+}
+
+// SendWithAttachments sends an email with in-memory attachments to a
+// single recipient and returns the message ID reported by the transport
+func (s *Sender) SendWithAttachments(
+	ctx context.Context,
+	sender, recipient, subject, body string,
+	attachments ...Attachment,
+) (string, error) {
+	return s.Send(ctx, &Message{
+		From:        sender,
+		To:          []string{recipient},
+		Subject:     subject,
+		HTMLBody:    body,
+		Attachments: attachments,
+	})
+}
+
+// Send delivers the message to its To, Cc and Bcc recipients and returns the
+// message ID reported by the transport. The configured Reply-To and Bcc
+// addresses are added to the message.
+func (s *Sender) Send(ctx context.Context, msg *Message) (string, error) {
+	withDefaults := *msg
+	if len(withDefaults.ReplyTo) == 0 {
+		withDefaults.ReplyTo = s.config.ReplyTo
 	}
+	withDefaults.Bcc = append(append([]string(nil), msg.Bcc...), s.config.Bcc...)
+	msg = &withDefaults
 
-	// Create a new SES client
-	svc := ses.New(sess)
-
-	// Send the raw email
-	input := &ses.SendRawEmailInput{
-		RawMessage: &ses.RawMessage{
-			Data: buf.Bytes(),
-		},
-		Source: aws.String(sender),
-		Destinations: []*string{
-			aws.String(recipient),
-		},
+	if len(msg.To) == 0 {
+		return "", fmt.Errorf("failed to send email: no recipients")
 	}
 
-	_, err = svc.SendRawEmail(input)
+	message, err := s.buildMessage(msg)
 	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+		return "", err
 	}
 
-	logger.Info("Email sent successfully to: %s", recipient)
-	return nil
-}
-
-// StripHTML removes HTML tags from a string to create plain text
-func StripHTML(html string) string {
-	var buf bytes.Buffer
-	inTag := false
-
-	for _, r := range html {
-		switch {
-		case r == '<':
-			inTag = true
-		case r == '>':
-			inTag = false
-		case !inTag:
-			buf.WriteRune(r)
-		}
+	// Deliver the raw message through the configured transport
+	recipients := msg.Recipients()
+	messageID, err := s.transport.Send(ctx, envelopeAddress(msg.From), recipients, message)
+	if err != nil {
+		return "", fmt.Errorf("failed to send email: %w", err)
 	}
 
-	return buf.String()
+	logger.Info("Email sent successfully to: %s", strings.Join(recipients, ", "))
+	logger.Debug("Message ID for %s: %s", strings.Join(msg.To, ", "), messageID)
+	return messageID, nil
 }
@@ -1,8 +1,14 @@
 package email
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ses"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -14,11 +20,44 @@ type MockSESAPI struct {
 	mock.Mock
 }
 
-func (m *MockSESAPI) SendRawEmail(input *ses.SendRawEmailInput) (*ses.SendRawEmailOutput, error) {
+func (m *MockSESAPI) SendRawEmailWithContext(
+	_ aws.Context,
+	input *ses.SendRawEmailInput,
+	_ ...request.Option,
+) (*ses.SendRawEmailOutput, error) {
 	args := m.Called(input)
 	return args.Get(0).(*ses.SendRawEmailOutput), args.Error(1)
 }
 
+func (m *MockSESAPI) GetSendQuotaWithContext(
+	_ aws.Context,
+	input *ses.GetSendQuotaInput,
+	_ ...request.Option,
+) (*ses.GetSendQuotaOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*ses.GetSendQuotaOutput), args.Error(1)
+}
+
+// recordingTransport captures messages instead of delivering them
+type recordingTransport struct {
+	sender     string
+	recipients []string
+	message    []byte
+	err        error
+}
+
+func (t *recordingTransport) Send(
+	_ context.Context,
+	sender string,
+	recipients []string,
+	message []byte,
+) (string, error) {
+	t.sender = sender
+	t.recipients = recipients
+	t.message = message
+	return "recorded-id", t.err
+}
+
 type EmailSenderTestSuite struct {
 	suite.Suite
 	sender  *Sender
@@ -42,47 +81,161 @@ func (suite *EmailSenderTestSuite) TestNewSender() {
 func (suite *EmailSenderTestSuite) TestDefaultConfig() {
 	config := DefaultConfig()
 	assert.Equal(suite.T(), "ap-southeast-2", config.Region)
+	assert.Equal(suite.T(), TransportSES, config.Transport)
 }
 
-func (suite *EmailSenderTestSuite) TestStripHTML() {
-	testCases := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{
-			name:     "Simple HTML",
-			input:    "<p>Hello <b>World</b></p>",
-			expected: "Hello World",
-		},
-		{
-			name:     "Complex HTML",
-			input:    "<html><head></head><body><p>Hello team,</p><p>Regards</p></body></html>",
-			expected: "Hello team,Regards",
-		},
-		{
-			name:     "No HTML",
-			input:    "Plain text message",
-			expected: "Plain text message",
-		},
-		{
-			name:     "Empty string",
-			input:    "",
-			expected: "",
-		},
-		{
-			name:     "HTML with attributes",
-			input:    `<div class="container"><span style="color: red;">Red text</span></div>`,
-			expected: "Red text",
-		},
-	}
-
-	for _, tc := range testCases {
-		suite.T().Run(tc.name, func(t *testing.T) {
-			result := StripHTML(tc.input)
-			assert.Equal(t, tc.expected, result)
-		})
-	}
+func (suite *EmailSenderTestSuite) TestNewSenderUsesConfiguredTransport() {
+	sender := NewSender(Config{Transport: TransportFile, File: FileConfig{Dir: "out"}})
+	assert.IsType(suite.T(), &FileTransport{}, sender.transport)
+
+	sender = NewSender(Config{Region: "us-east-1"})
+	assert.IsType(suite.T(), &SESTransport{}, sender.transport)
+	assert.NoError(suite.T(), sender.Err())
+}
+
+func (suite *EmailSenderTestSuite) TestNewSenderUnsupportedTransport() {
+	sender := NewSender(Config{Transport: "pigeon"})
+	assert.EqualError(suite.T(), sender.Err(), "unsupported mail transport: pigeon")
+
+	_, err := sender.SendWithAttachment(context.Background(), "a@example.com", "b@example.com", "s", "<p>b</p>", "f.csv", []byte("x"))
+
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "unsupported mail transport: pigeon")
+}
+
+func (suite *EmailSenderTestSuite) TestMaxSendRate() {
+	suite.mockSES.On("GetSendQuotaWithContext", mock.Anything).
+		Return(&ses.GetSendQuotaOutput{MaxSendRate: aws.Float64(14)}, nil)
+	sender := NewSenderWithTransport(DefaultConfig(), NewSESTransportWithClient(suite.mockSES))
+
+	assert.True(suite.T(), sender.RateLimited())
+	rate, err := sender.MaxSendRate(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 14.0, rate)
+
+	// Transports without a quota report no limit
+	unlimited := NewSenderWithTransport(DefaultConfig(), &recordingTransport{})
+	assert.False(suite.T(), unlimited.RateLimited())
+	rate, err = unlimited.MaxSendRate(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), rate)
+}
+
+func (suite *EmailSenderTestSuite) TestSendWithAttachmentDelegatesToTransport() {
+	transport := &recordingTransport{}
+	sender := NewSenderWithTransport(DefaultConfig(), transport)
+
+	id, err := sender.SendWithAttachment(
+		context.Background(),
+		"from@example.com",
+		"to@example.com",
+		"Test Subject",
+		"<p>Test Body</p>",
+		"test.csv",
+		[]byte("a,b\n1,2"),
+	)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "recorded-id", id)
+	assert.Equal(suite.T(), "from@example.com", transport.sender)
+	assert.Equal(suite.T(), []string{"to@example.com"}, transport.recipients)
+
+	message := string(transport.message)
+	assert.Contains(suite.T(), message, "Subject: Test Subject")
+	assert.Contains(suite.T(), message, "To: to@example.com")
+	assert.Contains(suite.T(), message, "<p>Test Body</p>")
+	assert.Contains(suite.T(), message, "filename=test.csv")
+}
+
+func (suite *EmailSenderTestSuite) TestSendWithAttachmentTransportError() {
+	transport := &recordingTransport{err: errors.New("connection refused")}
+	sender := NewSenderWithTransport(DefaultConfig(), transport)
+
+	_, err := sender.SendWithAttachment(context.Background(), "a@example.com", "b@example.com", "s", "<p>b</p>", "f.csv", []byte("x"))
+
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "failed to send email: connection refused")
+}
+
+func (suite *EmailSenderTestSuite) TestSendWithAttachmentFileMultiplePaths() {
+	dir := suite.T().TempDir()
+	pif := filepath.Join(dir, "pif.csv")
+	summary := filepath.Join(dir, "summary.pdf")
+	suite.Require().NoError(os.WriteFile(pif, []byte("Member Id\n12345\n"), 0o644))
+	suite.Require().NoError(os.WriteFile(summary, []byte("%PDF-1.4\n"), 0o644))
+	transport := &recordingTransport{}
+	sender := NewSenderWithTransport(DefaultConfig(), transport)
+
+	_, err := sender.SendWithAttachmentFile(
+		context.Background(), "from@example.com", "to@example.com", "Subject", "<p>Body</p>", pif, summary,
+	)
+
+	assert.NoError(suite.T(), err)
+	message := string(transport.message)
+	assert.Contains(suite.T(), message, "filename=pif.csv")
+	assert.Contains(suite.T(), message, "Content-Type: application/pdf; name=summary.pdf")
+	assert.Contains(suite.T(), message, "filename=summary.pdf")
+}
+
+func (suite *EmailSenderTestSuite) TestSendMultipleRecipients() {
+	transport := &recordingTransport{}
+	config := DefaultConfig()
+	config.ReplyTo = []string{"members@the-hub.ai"}
+	config.Bcc = []string{"headoffice@example.com"}
+	sender := NewSenderWithTransport(config, transport)
+
+	_, err := sender.Send(context.Background(), &Message{
+		From:     "Coral <from@example.com>",
+		To:       []string{"manager@example.com", "frontdesk@example.com"},
+		Cc:       []string{"owner@example.com"},
+		Bcc:      []string{"audit@example.com"},
+		Subject:  "Test Subject",
+		HTMLBody: "<p>Test Body</p>",
+	})
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "from@example.com", transport.sender)
+	assert.Equal(suite.T(), []string{
+		"manager@example.com",
+		"frontdesk@example.com",
+		"owner@example.com",
+		"audit@example.com",
+		"headoffice@example.com",
+	}, transport.recipients)
+
+	message := string(transport.message)
+	assert.Contains(suite.T(), message, "To: manager@example.com, frontdesk@example.com")
+	assert.Contains(suite.T(), message, "Cc: owner@example.com")
+	assert.Contains(suite.T(), message, "Reply-To: members@the-hub.ai")
+	assert.NotContains(suite.T(), message, "Bcc:")
+	assert.NotContains(suite.T(), message, "audit@example.com")
+}
+
+func (suite *EmailSenderTestSuite) TestSendKeepsMessageReplyTo() {
+	transport := &recordingTransport{}
+	config := DefaultConfig()
+	config.ReplyTo = []string{"members@the-hub.ai"}
+	sender := NewSenderWithTransport(config, transport)
+
+	_, err := sender.Send(context.Background(), &Message{
+		From:    "from@example.com",
+		To:      []string{"to@example.com"},
+		ReplyTo: []string{"club@example.com"},
+	})
+
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), string(transport.message), "Reply-To: club@example.com")
+	assert.NotContains(suite.T(), string(transport.message), "members@the-hub.ai")
+}
+
+func (suite *EmailSenderTestSuite) TestSendWithoutRecipients() {
+	transport := &recordingTransport{}
+	sender := NewSenderWithTransport(DefaultConfig(), transport)
+
+	_, err := sender.Send(context.Background(), &Message{From: "from@example.com", Cc: []string{"cc@example.com"}})
+
+	assert.EqualError(suite.T(), err, "failed to send email: no recipients")
+	assert.Nil(suite.T(), transport.message)
 }
 
 // Note: Testing SendWithAttachment and SendWithAttachmentFile would require
@@ -99,7 +252,7 @@ func (suite *EmailSenderTestSuite) TestSendWithAttachmentValidation() {
 	attachmentContent := []byte("test,data\n1,2")
 
 	// This will fail at AWS session creation, but we can test the input validation
-	err := suite.sender.SendWithAttachment(sender, recipient, subject, body, attachmentName, attachmentContent)
+	_, err := suite.sender.SendWithAttachment(context.Background(), sender, recipient, subject, body, attachmentName, attachmentContent)
 
 	// We expect an error because we don't have AWS credentials in test environment
 	// The error could be about AWS session, credentials, or region configuration
@@ -108,7 +261,8 @@ func (suite *EmailSenderTestSuite) TestSendWithAttachmentValidation() {
 }
 
 func (suite *EmailSenderTestSuite) TestSendWithAttachmentFileNotFound() {
-	err := suite.sender.SendWithAttachmentFile(
+	_, err := suite.sender.SendWithAttachmentFile(
+		context.Background(),
 		"test@example.com",
 		"recipient@example.com",
 		"Test Subject",
@@ -0,0 +1,101 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ses"
+)
+
+// SESAPI defines the subset of the AWS SES client used by SESTransport
+// This interface allows for mocking in tests
+type SESAPI interface {
+	SendRawEmailWithContext(
+		ctx aws.Context,
+		input *ses.SendRawEmailInput,
+		opts ...request.Option,
+	) (*ses.SendRawEmailOutput, error)
+	GetSendQuotaWithContext(
+		ctx aws.Context,
+		input *ses.GetSendQuotaInput,
+		opts ...request.Option,
+	) (*ses.GetSendQuotaOutput, error)
+}
+
+// SESTransport sends raw messages through AWS SES
+type SESTransport struct {
+	region string
+
+	once    sync.Once
+	client  SESAPI
+	initErr error
+}
+
+// NewSESTransport creates a new SES transport for the given region
+func NewSESTransport(region string) *SESTransport {
+	return &SESTransport{region: region}
+}
+
+// NewSESTransportWithClient creates a new SES transport using the given client
+func NewSESTransportWithClient(client SESAPI) *SESTransport {
+	t := &SESTransport{client: client}
+	t.once.Do(func() {})
+	return t
+}
+
+// Send sends the raw message via SES and returns the SES message ID
+func (t *SESTransport) Send(ctx context.Context, sender string, recipients []string, message []byte) (string, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	input := &ses.SendRawEmailInput{
+		RawMessage: &ses.RawMessage{
+			Data: message,
+		},
+		Source:       aws.String(sender),
+		Destinations: aws.StringSlice(recipients),
+	}
+
+	output, err := client.SendRawEmailWithContext(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(output.MessageId), nil
+}
+
+// MaxSendRate returns the maximum number of messages per second the SES
+// account is allowed to send
+func (t *SESTransport) MaxSendRate(ctx context.Context) (float64, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return 0, err
+	}
+
+	output, err := client.GetSendQuotaWithContext(ctx, &ses.GetSendQuotaInput{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get SES send quota: %w", err)
+	}
+	return aws.Float64Value(output.MaxSendRate), nil
+}
+
+// getClient lazily creates the SES client on first use
+func (t *SESTransport) getClient() (SESAPI, error) {
+	t.once.Do(func() {
+		sess, err := session.NewSession(&aws.Config{
+			Region: aws.String(t.region),
+		})
+		if err != nil {
+			t.initErr = fmt.Errorf("failed to create SES session: %w", err)
+			return
+		}
+		t.client = ses.New(sess)
+	})
+	return t.client, t.initErr
+}
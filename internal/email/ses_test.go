@@ -0,0 +1,65 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSESTransport_Send(t *testing.T) {
+	t.Run("should send raw message and return message id", func(t *testing.T) {
+		client := new(MockSESAPI)
+		client.On("SendRawEmailWithContext", mock.MatchedBy(func(input *ses.SendRawEmailInput) bool {
+			return aws.StringValue(input.Source) == "from@example.com" &&
+				len(input.Destinations) == 1 &&
+				aws.StringValue(input.Destinations[0]) == "to@example.com" &&
+				string(input.RawMessage.Data) == "raw message"
+		})).Return(&ses.SendRawEmailOutput{MessageId: aws.String("ses-123")}, nil)
+
+		transport := NewSESTransportWithClient(client)
+		id, err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, []byte("raw message"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "ses-123", id)
+		client.AssertExpectations(t)
+	})
+
+	t.Run("should return client error", func(t *testing.T) {
+		client := new(MockSESAPI)
+		client.On("SendRawEmailWithContext", mock.Anything).
+			Return((*ses.SendRawEmailOutput)(nil), errors.New("throttled"))
+
+		transport := NewSESTransportWithClient(client)
+		_, err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, []byte("raw message"))
+
+		assert.EqualError(t, err, "throttled")
+	})
+}
+
+func TestSESTransport_MaxSendRate(t *testing.T) {
+	t.Run("should return the account send rate", func(t *testing.T) {
+		client := new(MockSESAPI)
+		client.On("GetSendQuotaWithContext", mock.Anything).
+			Return(&ses.GetSendQuotaOutput{MaxSendRate: aws.Float64(14)}, nil)
+
+		rate, err := NewSESTransportWithClient(client).MaxSendRate(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 14.0, rate)
+	})
+
+	t.Run("should wrap client error", func(t *testing.T) {
+		client := new(MockSESAPI)
+		client.On("GetSendQuotaWithContext", mock.Anything).
+			Return((*ses.GetSendQuotaOutput)(nil), errors.New("access denied"))
+
+		_, err := NewSESTransportWithClient(client).MaxSendRate(context.Background())
+
+		assert.EqualError(t, err, "failed to get SES send quota: access denied")
+	})
+}
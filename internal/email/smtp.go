@@ -0,0 +1,358 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported SMTP connection security modes
+const (
+	// SMTPSecurityAuto upgrades the connection with STARTTLS when the server offers it
+	SMTPSecurityAuto = ""
+	// SMTPSecurityStartTLS requires the connection to be upgraded with STARTTLS
+	SMTPSecurityStartTLS = "starttls"
+	// SMTPSecurityTLS connects with implicit TLS (SMTPS)
+	SMTPSecurityTLS = "tls"
+	// SMTPSecurityNone never encrypts the connection, e.g. for a local test sink
+	SMTPSecurityNone = "none"
+)
+
+// Supported SMTP authentication mechanisms
+const (
+	// SMTPAuthAuto uses PLAIN when the server offers it and LOGIN otherwise
+	SMTPAuthAuto  = ""
+	SMTPAuthPlain = "plain"
+	SMTPAuthLogin = "login"
+)
+
+// smtpMaxIdle is the number of idle connections kept for reuse
+const smtpMaxIdle = 4
+
+// SMTPConfig contains SMTP server configuration
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+
+	// Security selects plain, STARTTLS or implicit TLS connections
+	Security string
+
+	// Auth selects the authentication mechanism used when Username is set
+	Auth string
+
+	// TLSConfig overrides the TLS settings, e.g. to trust a private CA
+	TLSConfig *tls.Config
+}
+
+// SMTPTransport sends messages through an SMTP relay. Connections are kept
+// open after a message is sent and reused for the following messages, so a
+// run opens at most one connection per concurrent sender.
+type SMTPTransport struct {
+	config SMTPConfig
+
+	mu     sync.Mutex
+	idle   []*smtpConn
+	closed bool
+}
+
+// smtpConn is an established and authenticated SMTP session
+type smtpConn struct {
+	conn   net.Conn
+	client *smtp.Client
+}
+
+// NewSMTPTransport creates a new SMTP transport with the given configuration
+func NewSMTPTransport(config SMTPConfig) *SMTPTransport {
+	return &SMTPTransport{config: config}
+}
+
+// Send delivers the message to the SMTP server
+func (t *SMTPTransport) Send(ctx context.Context, sender string, recipients []string, message []byte) (string, error) {
+	if t.config.Host == "" {
+		return "", fmt.Errorf("smtp host is not configured")
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	c, err := t.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.conn.SetDeadline(deadline)
+	}
+	// Abort the conversation if the context is cancelled mid-send
+	stop := context.AfterFunc(ctx, func() {
+		_ = c.conn.Close()
+	})
+
+	err = c.deliver(sender, recipients, message)
+
+	interrupted := !stop()
+	if interrupted || (err != nil && !isSMTPReply(err)) {
+		// The session is in an unknown state and cannot be reused
+		c.close()
+		return "", err
+	}
+	_ = c.conn.SetDeadline(time.Time{})
+	t.release(c)
+	return "", err
+}
+
+// Close ends every idle session. Sessions in use are closed when their send completes.
+func (t *SMTPTransport) Close() error {
+	t.mu.Lock()
+	idle := t.idle
+	t.idle = nil
+	t.closed = true
+	t.mu.Unlock()
+
+	for _, c := range idle {
+		_ = c.client.Quit()
+		c.close()
+	}
+	return nil
+}
+
+// acquire returns a reusable idle session or opens a new one
+func (t *SMTPTransport) acquire(ctx context.Context) (*smtpConn, error) {
+	for {
+		t.mu.Lock()
+		if len(t.idle) == 0 {
+			t.mu.Unlock()
+			return t.dial(ctx)
+		}
+		c := t.idle[len(t.idle)-1]
+		t.idle = t.idle[:len(t.idle)-1]
+		t.mu.Unlock()
+
+		// Servers drop idle sessions, so check the session is still alive
+		if err := c.client.Reset(); err == nil {
+			return c, nil
+		}
+		c.close()
+	}
+}
+
+// release returns a session to the idle pool
+func (t *SMTPTransport) release(c *smtpConn) {
+	t.mu.Lock()
+	if !t.closed && len(t.idle) < smtpMaxIdle {
+		t.idle = append(t.idle, c)
+		t.mu.Unlock()
+		return
+	}
+	t.mu.Unlock()
+
+	_ = c.client.Quit()
+	c.close()
+}
+
+// dial connects to the server, secures the connection and authenticates
+func (t *SMTPTransport) dial(ctx context.Context) (*smtpConn, error) {
+	security := strings.ToLower(t.config.Security)
+	switch security {
+	case SMTPSecurityAuto, SMTPSecurityStartTLS, SMTPSecurityTLS, SMTPSecurityNone:
+	default:
+		return nil, fmt.Errorf("unsupported smtp security mode: %s", t.config.Security)
+	}
+
+	addr := net.JoinHostPort(t.config.Host, strconv.Itoa(t.port()))
+	tlsConfig := t.tlsConfig()
+
+	var conn net.Conn
+	var err error
+	if security == SMTPSecurityTLS {
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stop()
+
+	client, err := smtp.NewClient(conn, t.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	c := &smtpConn{conn: conn, client: client}
+
+	if security == SMTPSecurityAuto || security == SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				c.close()
+				return nil, fmt.Errorf("failed to start tls: %w", err)
+			}
+		} else if security == SMTPSecurityStartTLS {
+			c.close()
+			return nil, fmt.Errorf("smtp server does not support STARTTLS")
+		}
+	}
+
+	if t.config.Username != "" {
+		auth, err := t.auth(client)
+		if err != nil {
+			c.close()
+			return nil, err
+		}
+		if err := client.Auth(auth); err != nil {
+			c.close()
+			return nil, fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+// port returns the configured port or the default port of the security mode
+func (t *SMTPTransport) port() int {
+	if t.config.Port != 0 {
+		return t.config.Port
+	}
+	switch strings.ToLower(t.config.Security) {
+	case SMTPSecurityTLS:
+		return 465
+	case SMTPSecurityNone:
+		return 25
+	default:
+		return 587
+	}
+}
+
+// tlsConfig returns the TLS settings used for STARTTLS and implicit TLS
+func (t *SMTPTransport) tlsConfig() *tls.Config {
+	if t.config.TLSConfig != nil {
+		config := t.config.TLSConfig.Clone()
+		if config.ServerName == "" {
+			config.ServerName = t.config.Host
+		}
+		return config
+	}
+	return &tls.Config{ServerName: t.config.Host}
+}
+
+// auth returns the configured authentication mechanism
+func (t *SMTPTransport) auth(client *smtp.Client) (smtp.Auth, error) {
+	mechanism := strings.ToLower(t.config.Auth)
+	if mechanism == SMTPAuthAuto {
+		mechanism = SMTPAuthPlain
+		if ok, mechanisms := client.Extension("AUTH"); ok && !hasMechanism(mechanisms, "PLAIN") &&
+			hasMechanism(mechanisms, "LOGIN") {
+			mechanism = SMTPAuthLogin
+		}
+	}
+
+	switch mechanism {
+	case SMTPAuthPlain:
+		return smtp.PlainAuth("", t.config.Username, t.config.Password, t.config.Host), nil
+	case SMTPAuthLogin:
+		return &loginAuth{username: t.config.Username, password: t.config.Password, host: t.config.Host}, nil
+	default:
+		return nil, fmt.Errorf("unsupported smtp auth mechanism: %s", t.config.Auth)
+	}
+}
+
+// deliver runs a single mail transaction on the session
+func (c *smtpConn) deliver(sender string, recipients []string, message []byte) error {
+	if err := c.client.Mail(sender); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := c.client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	writer, err := c.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// close drops the session without a QUIT
+func (c *smtpConn) close() {
+	_ = c.client.Close()
+}
+
+// isSMTPReply reports whether err is a reply from the server, after which
+// the session can still be used for the next transaction
+func isSMTPReply(err error) bool {
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr)
+}
+
+// hasMechanism reports whether an AUTH extension parameter lists the mechanism
+func hasMechanism(mechanisms, mechanism string) bool {
+	for _, m := range strings.Fields(mechanisms) {
+		if strings.EqualFold(m, mechanism) {
+			return true
+		}
+	}
+	return false
+}
+
+// loginAuth implements the LOGIN authentication mechanism, which
+// net/smtp does not provide but many Microsoft-hosted relays require
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	// Like PLAIN, never send credentials over an unencrypted remote connection
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	prompt := strings.ToLower(strings.TrimSpace(string(fromServer)))
+	switch {
+	case strings.HasPrefix(prompt, "username"):
+		return []byte(a.username), nil
+	case strings.HasPrefix(prompt, "password"):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected smtp login prompt: %s", fromServer)
+	}
+}
+
+// isLocalhost reports whether the host is the local machine
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
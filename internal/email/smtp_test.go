@@ -0,0 +1,334 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer is a minimal SMTP sink recording the sessions and messages it receives
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+
+	// startTLS advertises STARTTLS, authMechanisms is the AUTH extension parameter
+	startTLS       bool
+	authMechanisms string
+	rejectRcpt     string
+
+	mu          sync.Mutex
+	connections int
+	auths       []string
+	messages    []string
+	tlsUsed     []bool
+}
+
+func newFakeSMTPServer(t *testing.T, implicitTLS bool) *fakeSMTPServer {
+	t.Helper()
+
+	cert := newTestCertificate(t)
+	server := &fakeSMTPServer{tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}}}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if implicitTLS {
+		listener = tls.NewListener(listener, server.tlsConfig)
+	}
+	server.listener = listener
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go server.serve()
+	return server
+}
+
+// clientTLSConfig trusts the server's self-signed certificate
+func (s *fakeSMTPServer) clientTLSConfig() *tls.Config {
+	pool := x509.NewCertPool()
+	pool.AddCert(s.tlsConfig.Certificates[0].Leaf)
+	return &tls.Config{RootCAs: pool}
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) stats() (connections int, auths []string, messages []string, tlsUsed []bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections, append([]string(nil), s.auths...), append([]string(nil), s.messages...),
+		append([]bool(nil), s.tlsUsed...)
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connections++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+	_, secure := conn.(*tls.Conn)
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO":
+			reply("250-fake")
+			if s.startTLS && !secure {
+				reply("250-STARTTLS")
+			}
+			if s.authMechanisms != "" {
+				reply("250-AUTH " + s.authMechanisms)
+			}
+			reply("250 8BITMIME")
+		case "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			reader = bufio.NewReader(conn)
+		case "AUTH":
+			fields := strings.Fields(line)
+			switch strings.ToUpper(fields[1]) {
+			case "PLAIN":
+				decoded, _ := base64.StdEncoding.DecodeString(fields[2])
+				s.recordAuth("PLAIN " + strings.ReplaceAll(string(decoded), "\x00", ":"))
+			case "LOGIN":
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+				user, _ := reader.ReadString('\n')
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+				pass, _ := reader.ReadString('\n')
+				u, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(user))
+				p, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(pass))
+				s.recordAuth("LOGIN " + string(u) + ":" + string(p))
+			}
+			reply("235 authenticated")
+		case "MAIL", "RSET", "NOOP":
+			reply("250 ok")
+		case "RCPT":
+			if s.rejectRcpt != "" && strings.Contains(line, s.rejectRcpt) {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.tlsUsed = append(s.tlsUsed, secure)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) recordAuth(auth string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auths = append(s.auths, auth)
+}
+
+// newTestCertificate creates a self-signed certificate for 127.0.0.1
+func newTestCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func sendTestMessages(t *testing.T, transport *SMTPTransport, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		message := []byte("Subject: hi\r\n\r\nbody\r\n")
+		_, err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, message)
+		require.NoError(t, err)
+	}
+}
+
+func TestSMTPTransport_Send(t *testing.T) {
+	t.Run("should require a host", func(t *testing.T) {
+		_, err := NewSMTPTransport(SMTPConfig{}).Send(context.Background(), "a@example.com", nil, nil)
+		assert.EqualError(t, err, "smtp host is not configured")
+	})
+
+	t.Run("should send over a plain connection and reuse it", func(t *testing.T) {
+		server := newFakeSMTPServer(t, false)
+		transport := NewSMTPTransport(SMTPConfig{Host: "127.0.0.1", Port: server.port(), Security: SMTPSecurityNone})
+
+		sendTestMessages(t, transport, 3)
+		require.NoError(t, transport.Close())
+
+		connections, _, messages, tlsUsed := server.stats()
+		assert.Equal(t, 1, connections)
+		assert.Len(t, messages, 3)
+		assert.Contains(t, messages[0], "Subject: hi")
+		assert.Equal(t, []bool{false, false, false}, tlsUsed)
+	})
+
+	t.Run("should upgrade with STARTTLS and authenticate with PLAIN", func(t *testing.T) {
+		server := newFakeSMTPServer(t, false)
+		server.startTLS = true
+		server.authMechanisms = "PLAIN LOGIN"
+		transport := NewSMTPTransport(SMTPConfig{
+			Host:      "127.0.0.1",
+			Port:      server.port(),
+			Username:  "user",
+			Password:  "secret",
+			Security:  SMTPSecurityStartTLS,
+			TLSConfig: server.clientTLSConfig(),
+		})
+
+		sendTestMessages(t, transport, 2)
+		require.NoError(t, transport.Close())
+
+		connections, auths, messages, tlsUsed := server.stats()
+		assert.Equal(t, 1, connections)
+		assert.Equal(t, []string{"PLAIN :user:secret"}, auths)
+		assert.Len(t, messages, 2)
+		assert.Equal(t, []bool{true, true}, tlsUsed)
+	})
+
+	t.Run("should fail when STARTTLS is required but not offered", func(t *testing.T) {
+		server := newFakeSMTPServer(t, false)
+		transport := NewSMTPTransport(SMTPConfig{Host: "127.0.0.1", Port: server.port(), Security: SMTPSecurityStartTLS})
+
+		_, err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, []byte("x"))
+
+		assert.EqualError(t, err, "smtp server does not support STARTTLS")
+	})
+
+	t.Run("should connect with implicit TLS and authenticate with LOGIN", func(t *testing.T) {
+		server := newFakeSMTPServer(t, true)
+		server.authMechanisms = "LOGIN"
+		transport := NewSMTPTransport(SMTPConfig{
+			Host:      "127.0.0.1",
+			Port:      server.port(),
+			Username:  "user",
+			Password:  "secret",
+			Security:  SMTPSecurityTLS,
+			TLSConfig: server.clientTLSConfig(),
+		})
+
+		sendTestMessages(t, transport, 1)
+		require.NoError(t, transport.Close())
+
+		_, auths, messages, tlsUsed := server.stats()
+		assert.Equal(t, []string{"LOGIN user:secret"}, auths)
+		assert.Len(t, messages, 1)
+		assert.Equal(t, []bool{true}, tlsUsed)
+	})
+
+	t.Run("should keep the session after a rejected recipient", func(t *testing.T) {
+		server := newFakeSMTPServer(t, false)
+		server.rejectRcpt = "bad@example.com"
+		transport := NewSMTPTransport(SMTPConfig{Host: "127.0.0.1", Port: server.port(), Security: SMTPSecurityNone})
+
+		_, err := transport.Send(context.Background(), "from@example.com", []string{"bad@example.com"}, []byte("x"))
+		assert.Error(t, err)
+		assert.False(t, IsRetryable(err))
+
+		sendTestMessages(t, transport, 1)
+		require.NoError(t, transport.Close())
+
+		connections, _, messages, _ := server.stats()
+		assert.Equal(t, 1, connections)
+		assert.Len(t, messages, 1)
+	})
+
+	t.Run("should reject unknown security mode and auth mechanism", func(t *testing.T) {
+		server := newFakeSMTPServer(t, false)
+
+		transport := NewSMTPTransport(SMTPConfig{Host: "127.0.0.1", Port: server.port(), Security: "ssl3"})
+		_, err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, []byte("x"))
+		assert.EqualError(t, err, "unsupported smtp security mode: ssl3")
+
+		transport = NewSMTPTransport(SMTPConfig{
+			Host:     "127.0.0.1",
+			Port:     server.port(),
+			Security: SMTPSecurityNone,
+			Username: "user",
+			Auth:     "cram-md5",
+		})
+		_, err = transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, []byte("x"))
+		assert.EqualError(t, err, "unsupported smtp auth mechanism: cram-md5")
+	})
+}
+
+func TestSMTPTransport_DefaultPort(t *testing.T) {
+	assert.Equal(t, 587, NewSMTPTransport(SMTPConfig{}).port())
+	assert.Equal(t, 465, NewSMTPTransport(SMTPConfig{Security: SMTPSecurityTLS}).port())
+	assert.Equal(t, 25, NewSMTPTransport(SMTPConfig{Security: SMTPSecurityNone}).port())
+	assert.Equal(t, 1025, NewSMTPTransport(SMTPConfig{Port: 1025}).port())
+}
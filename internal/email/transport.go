@@ -0,0 +1,54 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Supported mail transport names
+const (
+	TransportSES  = "ses"
+	TransportSMTP = "smtp"
+	TransportFile = "file"
+)
+
+// Transport delivers a fully rendered MIME message to its recipients.
+// It returns an identifier for the delivered message where the transport
+// provides one (e.g. the SES message ID or the path of a written file).
+type Transport interface {
+	Send(ctx context.Context, sender string, recipients []string, message []byte) (string, error)
+}
+
+// RateLimitedTransport is implemented by transports that enforce a maximum
+// sending rate, such as SES
+type RateLimitedTransport interface {
+	MaxSendRate(ctx context.Context) (float64, error)
+}
+
+// NewTransport creates the transport selected by the configuration
+func NewTransport(config Config) (Transport, error) {
+	switch strings.ToLower(config.Transport) {
+	case "", TransportSES:
+		return NewSESTransport(config.Region), nil
+	case TransportSMTP:
+		if config.SMTP.Host == "" {
+			return nil, fmt.Errorf("smtp host is not configured")
+		}
+		return NewSMTPTransport(config.SMTP), nil
+	case TransportFile:
+		return NewFileTransport(config.File), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail transport: %s", config.Transport)
+	}
+}
+
+// unsupportedTransport is used when the configured transport is invalid so
+// that the error is reported by Sender.Err and fails every message sent
+type unsupportedTransport struct {
+	err error
+}
+
+func (t *unsupportedTransport) Send(context.Context, string, []string, []byte) (string, error) {
+	return "", t.err
+}
@@ -0,0 +1,70 @@
+package email
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTransport(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		expected    Transport
+		expectedErr string
+	}{
+		{
+			name:     "empty transport defaults to ses",
+			config:   Config{Region: "us-east-1"},
+			expected: &SESTransport{},
+		},
+		{
+			name:     "ses transport",
+			config:   Config{Transport: "SES"},
+			expected: &SESTransport{},
+		},
+		{
+			name:     "smtp transport",
+			config:   Config{Transport: TransportSMTP, SMTP: SMTPConfig{Host: "localhost"}},
+			expected: &SMTPTransport{},
+		},
+		{
+			name:     "file transport",
+			config:   Config{Transport: TransportFile, File: FileConfig{Dir: "out"}},
+			expected: &FileTransport{},
+		},
+		{
+			name:        "smtp transport without host",
+			config:      Config{Transport: TransportSMTP},
+			expectedErr: "smtp host is not configured",
+		},
+		{
+			name:        "unsupported transport",
+			config:      Config{Transport: "pigeon"},
+			expectedErr: "unsupported mail transport: pigeon",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewTransport(tt.config)
+
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, transport)
+				return
+			}
+			assert.NoError(t, err)
+			assert.IsType(t, tt.expected, transport)
+		})
+	}
+}
+
+func TestSMTPTransport_SendWithoutHost(t *testing.T) {
+	transport := NewSMTPTransport(SMTPConfig{})
+
+	_, err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, []byte("message"))
+
+	assert.EqualError(t, err, "smtp host is not configured")
+}
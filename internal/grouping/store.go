@@ -0,0 +1,243 @@
+// Package grouping collects club transfers per club, spilling them to
+// temporary files when there are too many to keep in memory
+package grouping
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"coral.daniel-guo.com/internal/model"
+)
+
+// Config configures when and where transfers are spilled to disk
+type Config struct {
+	// MaxInMemory is the number of transfers held in memory before they are
+	// written to per-club temporary files. 0 keeps every transfer in memory.
+	MaxInMemory int
+
+	// Dir is the directory temporary files are created in, the system
+	// temporary directory when empty
+	Dir string
+}
+
+// DefaultConfig returns a configuration that spills after 100,000 transfers
+func DefaultConfig() Config {
+	return Config{MaxInMemory: 100000}
+}
+
+// Store groups club transfers by club. Transfers are held in memory until
+// more than MaxInMemory have been added, after which they are appended to a
+// JSON lines file per club and read back one club at a time. Once every
+// transfer has been added, clubs may be read concurrently. A store must be
+// closed to remove its temporary files.
+type Store struct {
+	config Config
+
+	mu       sync.Mutex
+	memory   map[string][]model.ClubTransferData
+	inMemory int
+	counts   map[string]int
+
+	// dir holds the spill files, created on the first spill
+	dir   string
+	files map[string]string
+}
+
+// NewStore creates an empty store
+func NewStore(config Config) *Store {
+	return &Store{
+		config: config,
+		memory: make(map[string][]model.ClubTransferData),
+		counts: make(map[string]int),
+		files:  make(map[string]string),
+	}
+}
+
+// Add adds a transfer to a club, spilling the transfers held in memory when
+// there are more than the configured maximum
+func (s *Store) Add(club string, transfer model.ClubTransferData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.memory[club] = append(s.memory[club], transfer)
+	s.counts[club]++
+	s.inMemory++
+
+	if s.config.MaxInMemory > 0 && s.inMemory > s.config.MaxInMemory {
+		return s.spill()
+	}
+	return nil
+}
+
+// Merge moves the transfers of a club to another club, after the transfers
+// already added to it. It must not be called while clubs are being read.
+func (s *Store) Merge(from, into string) error {
+	if from == into {
+		return nil
+	}
+	transfers, err := s.Transfers(from)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inMemory -= len(s.memory[from])
+	path, spilled := s.files[from]
+	delete(s.memory, from)
+	delete(s.counts, from)
+	delete(s.files, from)
+	if spilled {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to merge transfers of club %s: %w", from, err)
+		}
+	}
+
+	s.memory[into] = append(s.memory[into], transfers...)
+	s.counts[into] += len(transfers)
+	s.inMemory += len(transfers)
+	if s.config.MaxInMemory > 0 && s.inMemory > s.config.MaxInMemory {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill appends the transfers held in memory to the club files
+func (s *Store) spill() error {
+	if s.dir == "" {
+		dir, err := os.MkdirTemp(s.config.Dir, "club-transfers-")
+		if err != nil {
+			return fmt.Errorf("failed to create spill directory: %w", err)
+		}
+		s.dir = dir
+	}
+
+	for club, transfers := range s.memory {
+		path, ok := s.files[club]
+		if !ok {
+			// Club names are not safe file names, so files are numbered
+			path = filepath.Join(s.dir, fmt.Sprintf("%d.jsonl", len(s.files)))
+			s.files[club] = path
+		}
+		if err := appendTransfers(path, transfers); err != nil {
+			return fmt.Errorf("failed to spill transfers for club %s: %w", club, err)
+		}
+	}
+
+	s.memory = make(map[string][]model.ClubTransferData)
+	s.inMemory = 0
+	return nil
+}
+
+// appendTransfers appends transfers to a JSON lines file. The file is only
+// open while writing, so the number of clubs is not limited by open files.
+func appendTransfers(path string, transfers []model.ClubTransferData) (err error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	for _, transfer := range transfers {
+		if err := encoder.Encode(transfer); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Clubs returns the names of the clubs with transfers in sorted order
+func (s *Store) Clubs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clubs := make([]string, 0, len(s.counts))
+	for club := range s.counts {
+		clubs = append(clubs, club)
+	}
+	sort.Strings(clubs)
+	return clubs
+}
+
+// Len returns the number of clubs with transfers
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.counts)
+}
+
+// Count returns the number of transfers of a club
+func (s *Store) Count(club string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[club]
+}
+
+// Spilled reports whether any transfers have been written to disk
+func (s *Store) Spilled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dir != ""
+}
+
+// Transfers returns the transfers of a club in the order they were added
+func (s *Store) Transfers(club string) ([]model.ClubTransferData, error) {
+	s.mu.Lock()
+	path, spilled := s.files[club]
+	inMemory := s.memory[club]
+	count := s.counts[club]
+	s.mu.Unlock()
+
+	if !spilled {
+		return inMemory, nil
+	}
+
+	transfers := make([]model.ClubTransferData, 0, count)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transfers for club %s: %w", club, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for {
+		var transfer model.ClubTransferData
+		if err := decoder.Decode(&transfer); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read transfers for club %s: %w", club, err)
+		}
+		transfers = append(transfers, transfer)
+	}
+	return append(transfers, inMemory...), nil
+}
+
+// Close removes the temporary files
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dir == "" {
+		return nil
+	}
+	err := os.RemoveAll(s.dir)
+	s.dir = ""
+	s.files = make(map[string]string)
+	return err
+}
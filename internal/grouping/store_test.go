@@ -0,0 +1,132 @@
+package grouping
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"coral.daniel-guo.com/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func transfer(id, club string) model.ClubTransferData {
+	return model.ClubTransferData{
+		MemberID:     id,
+		HomeClub:     club,
+		TargetClub:   "CLUB Z",
+		TransferType: "TRANSFER OUT",
+		TransferDate: time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestStoreInMemory(t *testing.T) {
+	store := NewStore(Config{})
+	defer func() {
+		assert.NoError(t, store.Close())
+	}()
+
+	require.NoError(t, store.Add("CLUB B", transfer("1", "CLUB B")))
+	require.NoError(t, store.Add("CLUB A", transfer("2", "CLUB A")))
+	require.NoError(t, store.Add("CLUB B", transfer("3", "CLUB B")))
+
+	assert.Equal(t, []string{"CLUB A", "CLUB B"}, store.Clubs())
+	assert.Equal(t, 2, store.Len())
+	assert.Equal(t, 2, store.Count("CLUB B"))
+	assert.Equal(t, 0, store.Count("CLUB C"))
+	assert.False(t, store.Spilled())
+
+	transfers, err := store.Transfers("CLUB B")
+	require.NoError(t, err)
+	assert.Equal(t, []model.ClubTransferData{transfer("1", "CLUB B"), transfer("3", "CLUB B")}, transfers)
+
+	transfers, err = store.Transfers("CLUB C")
+	require.NoError(t, err)
+	assert.Empty(t, transfers)
+}
+
+func TestStoreSpill(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(Config{MaxInMemory: 3, Dir: dir})
+
+	var want []model.ClubTransferData
+	for i := 0; i < 10; i++ {
+		club := fmt.Sprintf("CLUB/%d", i%2)
+		require.NoError(t, store.Add(club, transfer(fmt.Sprint(i), club)))
+		if i%2 == 0 {
+			want = append(want, transfer(fmt.Sprint(i), club))
+		}
+	}
+	assert.True(t, store.Spilled())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// Spilled and in-memory transfers come back in the order they were added
+	transfers, err := store.Transfers("CLUB/0")
+	require.NoError(t, err)
+	assert.Equal(t, want, transfers)
+	assert.Equal(t, 5, store.Count("CLUB/1"))
+
+	var wg sync.WaitGroup
+	for _, club := range store.Clubs() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			transfers, err := store.Transfers(club)
+			assert.NoError(t, err)
+			assert.Len(t, transfers, 5)
+		}()
+	}
+	wg.Wait()
+
+	require.NoError(t, store.Close())
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestStoreSpillFailure(t *testing.T) {
+	store := NewStore(Config{MaxInMemory: 1, Dir: "/nonexistent/spill"})
+
+	require.NoError(t, store.Add("CLUB A", transfer("1", "CLUB A")))
+	err := store.Add("CLUB A", transfer("2", "CLUB A"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create spill directory")
+}
+
+func TestStoreMerge(t *testing.T) {
+	for _, maxInMemory := range []int{0, 2} {
+		t.Run(fmt.Sprintf("max in memory %d", maxInMemory), func(t *testing.T) {
+			store := NewStore(Config{MaxInMemory: maxInMemory, Dir: t.TempDir()})
+			defer func() {
+				assert.NoError(t, store.Close())
+			}()
+
+			require.NoError(t, store.Add("ST KILDA", transfer("1", "ST KILDA")))
+			require.NoError(t, store.Add("ST KILDA BEACH", transfer("2", "ST KILDA BEACH")))
+			require.NoError(t, store.Add("ST KILDA", transfer("3", "ST KILDA")))
+			require.NoError(t, store.Add("CLUB A", transfer("4", "CLUB A")))
+
+			require.NoError(t, store.Merge("ST KILDA BEACH", "ST KILDA"))
+			require.NoError(t, store.Merge("CLUB A", "CLUB A"))
+
+			assert.Equal(t, []string{"CLUB A", "ST KILDA"}, store.Clubs())
+			assert.Equal(t, 3, store.Count("ST KILDA"))
+			transfers, err := store.Transfers("ST KILDA")
+			require.NoError(t, err)
+			ids := make([]string, 0, len(transfers))
+			for _, transfer := range transfers {
+				ids = append(ids, transfer.MemberID)
+			}
+			assert.Equal(t, []string{"1", "3", "2"}, ids)
+
+			merged, err := store.Transfers("ST KILDA BEACH")
+			require.NoError(t, err)
+			assert.Empty(t, merged)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Package history keeps a local record of the transfers clubs have been
+// notified of, so a transfer sent in one run is not sent again in the next
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"coral.daniel-guo.com/internal/locations"
+)
+
+// Key identifies a transfer: the same member moving between the same clubs
+type Key struct {
+	MemberID   string
+	HomeClub   string
+	TargetClub string
+}
+
+// NewKey creates a key, comparing club names normalised (see locations.Normalize)
+func NewKey(memberID, homeClub, targetClub string) Key {
+	return Key{
+		MemberID:   strings.TrimSpace(memberID),
+		HomeClub:   locations.Normalize(homeClub),
+		TargetClub: locations.Normalize(targetClub),
+	}
+}
+
+// Entry records a transfer notified to a club in a run
+type Entry struct {
+	MemberID   string `json:"member_id"`
+	HomeClub   string `json:"home_club"`
+	TargetClub string `json:"target_club"`
+	// Club is the home or target club notified of the transfer. Entries
+	// without one stand for both clubs.
+	Club         string    `json:"club,omitempty"`
+	TransferType string    `json:"transfer_type"`
+	Period       string    `json:"period"`
+	NotifiedAt   time.Time `json:"notified_at"`
+}
+
+// Key returns the key of the entry's transfer
+func (e Entry) Key() Key {
+	return NewKey(e.MemberID, e.HomeClub, e.TargetClub)
+}
+
+// notified reports whether the entry records the transfer being notified to the club
+func (e Entry) notified(club string) bool {
+	return e.Club == "" || locations.Normalize(e.Club) == locations.Normalize(club)
+}
+
+// sameRun reports whether two entries were recorded by runs of the same transfer type and period
+func (e Entry) sameRun(other Entry) bool {
+	return strings.EqualFold(e.TransferType, other.TransferType) && e.Period == other.Period
+}
+
+// History is a concurrency-safe record of notified transfers, stored as a
+// JSON lines file that is only ever appended to
+type History struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[Key][]Entry
+}
+
+// Open loads the history at path, or starts a new one if the file does not exist
+func Open(path string) (*History, error) {
+	h := &History{path: path, entries: make(map[Key][]Entry)}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse history %s line %d: %w", path, line, err)
+		}
+		h.entries[entry.Key()] = append(h.entries[entry.Key()], entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return h, nil
+}
+
+// Path returns the location of the history file
+func (h *History) Path() string {
+	return h.path
+}
+
+// Previous returns the most recent entry for the transfer notified to the
+// club by a different run than the given transfer type and period, so that
+// re-running or resuming a run does not find its own transfers
+func (h *History) Previous(key Key, club, transferType, period string) (Entry, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	run := Entry{TransferType: transferType, Period: period}
+	entries := h.entries[key]
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].sameRun(run) && entries[i].notified(club) {
+			return entries[i], true
+		}
+	}
+	return Entry{}, false
+}
+
+// Record appends the entries not yet recorded for their run and club to the history file
+func (h *History) Record(entries ...Entry) (err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var added []Entry
+	for _, entry := range entries {
+		if entry.NotifiedAt.IsZero() {
+			entry.NotifiedAt = time.Now()
+		}
+		if h.recorded(entry) {
+			continue
+		}
+		h.entries[entry.Key()] = append(h.entries[entry.Key()], entry)
+		added = append(added, entry)
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer func() {
+		if cerr := file.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("failed to write history: %w", cerr)
+		}
+	}()
+
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	for _, entry := range added {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to write history: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// recorded reports whether the entry's transfer is already recorded for its run and club
+func (h *History) recorded(entry Entry) bool {
+	for _, existing := range h.entries[entry.Key()] {
+		if existing.sameRun(entry) && existing.notified(entry.Club) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKey(t *testing.T) {
+	assert.Equal(t, Key{MemberID: "12345", HomeClub: "CLUB A", TargetClub: "ST KILDA"},
+		NewKey(" 12345 ", "club a", "St.  Kilda "))
+}
+
+func TestHistoryRecordAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal", "history.jsonl")
+
+	h, err := Open(path)
+	require.NoError(t, err)
+	key := NewKey("12345", "CLUB A", "CLUB B")
+	_, found := h.Previous(key, "CLUB A", "PIF", "2025-05")
+	assert.False(t, found)
+
+	april := Entry{MemberID: "12345", HomeClub: "CLUB A", TargetClub: "CLUB B", Club: "CLUB A",
+		TransferType: "PIF", Period: "2025-04"}
+	require.NoError(t, h.Record(april, april))
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	previous, found := reopened.Previous(NewKey("12345", "club a", "club b"), "club a", "PIF", "2025-05")
+	require.True(t, found)
+	assert.Equal(t, "2025-04", previous.Period)
+	assert.False(t, previous.NotifiedAt.IsZero())
+
+	// Only the club that was notified finds the transfer
+	_, found = reopened.Previous(key, "CLUB B", "PIF", "2025-05")
+	assert.False(t, found)
+
+	// The run that recorded the transfer does not find it again
+	_, found = reopened.Previous(key, "CLUB A", "pif", "2025-04")
+	assert.False(t, found)
+
+	// Entries already recorded for their run are not written again
+	require.NoError(t, reopened.Record(april))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(content), "\n"))
+}
+
+func TestHistoryPreviousReturnsMostRecent(t *testing.T) {
+	h, err := Open(filepath.Join(t.TempDir(), "history.jsonl"))
+	require.NoError(t, err)
+
+	notified := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, h.Record(
+		Entry{MemberID: "1", HomeClub: "A", TargetClub: "B", TransferType: "PIF", Period: "2025-03", NotifiedAt: notified},
+		Entry{MemberID: "1", HomeClub: "A", TargetClub: "B", TransferType: "PIF", Period: "2025-04", NotifiedAt: notified},
+		Entry{MemberID: "1", HomeClub: "A", TargetClub: "B", TransferType: "PIF", Period: "2025-05", NotifiedAt: notified},
+	))
+
+	previous, found := h.Previous(NewKey("1", "A", "B"), "A", "PIF", "2025-05")
+	require.True(t, found)
+	assert.Equal(t, "2025-04", previous.Period)
+	assert.Equal(t, notified, previous.NotifiedAt)
+}
+
+func TestHistoryEntriesWithoutClub(t *testing.T) {
+	h, err := Open(filepath.Join(t.TempDir(), "history.jsonl"))
+	require.NoError(t, err)
+
+	// Entries recorded without a club stand for both clubs
+	require.NoError(t, h.Record(Entry{
+		MemberID: "1", HomeClub: "A", TargetClub: "B", TransferType: "PIF", Period: "2025-04",
+	}))
+
+	for _, club := range []string{"A", "B"} {
+		_, found := h.Previous(NewKey("1", "A", "B"), club, "PIF", "2025-05")
+		assert.True(t, found, "club %s", club)
+	}
+}
+
+func TestHistoryConcurrentRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	h, err := Open(path)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"1", "2", "3", "4"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, h.Record(Entry{MemberID: id, HomeClub: "A", TargetClub: "B", Club: "A", TransferType: "DD"}))
+		}()
+	}
+	wg.Wait()
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	assert.Len(t, reopened.entries, 4)
+}
+
+func TestOpenInvalidHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"member_id\":\"1\"}\n\nnot json\n"), 0o644))
+
+	_, err := Open(path)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3")
+}
@@ -0,0 +1,208 @@
+// Package journal records the delivery outcome of every club in a run so
+// that interrupted or partially failed runs can be resumed
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"coral.daniel-guo.com/internal/model"
+)
+
+// Status is the delivery status of a club
+type Status string
+
+const (
+	// StatusSent means the club email was delivered
+	StatusSent Status = "sent"
+	// StatusFailed means the last attempt to deliver the club email failed
+	StatusFailed Status = "failed"
+	// StatusUnsent means the run was cancelled before the club email was attempted
+	StatusUnsent Status = "unsent"
+)
+
+// Key identifies a run: the same input file, transfer type and period share a journal
+type Key struct {
+	FileHash     string `json:"file_hash"`
+	TransferType string `json:"transfer_type"`
+	Period       string `json:"period"`
+}
+
+// NewKey creates a journal key for the given input file hash, transfer type and period
+func NewKey(fileHash, transferType string, period model.Period) Key {
+	return Key{
+		FileHash:     fileHash,
+		TransferType: strings.ToUpper(transferType),
+		Period:       period.Code(),
+	}
+}
+
+// fileName returns the journal file name for the key
+func (k Key) fileName() string {
+	hash := k.FileHash
+	if len(hash) > 16 {
+		hash = hash[:16]
+	}
+	return fmt.Sprintf("%s_%s_%s.json", strings.ToLower(k.TransferType), k.Period, hash)
+}
+
+// Entry is the recorded outcome of a single club
+type Entry struct {
+	Club      string    `json:"club"`
+	Status    Status    `json:"status"`
+	Recipient string    `json:"recipient,omitempty"`
+	MessageID string    `json:"message_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Attempts  int       `json:"attempts"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// document is the on-disk representation of a journal
+type document struct {
+	Key   Key               `json:"key"`
+	Clubs map[string]*Entry `json:"clubs"`
+}
+
+// Journal is a persistent, concurrency-safe record of club delivery outcomes
+type Journal struct {
+	path    string
+	existed bool
+
+	mu  sync.Mutex
+	doc document
+}
+
+// Open loads the journal for the key from dir, or starts a new one if none exists
+func Open(dir string, key Key) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	j := &Journal{
+		path: filepath.Join(dir, key.fileName()),
+		doc: document{
+			Key:   key,
+			Clubs: make(map[string]*Entry),
+		},
+	}
+
+	content, err := os.ReadFile(j.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	j.existed = true
+	if err := json.Unmarshal(content, &j.doc); err != nil {
+		return nil, fmt.Errorf("failed to parse journal %s: %w", j.path, err)
+	}
+	if j.doc.Key != key {
+		return nil, fmt.Errorf("journal %s belongs to a different run", j.path)
+	}
+	if j.doc.Clubs == nil {
+		j.doc.Clubs = make(map[string]*Entry)
+	}
+	return j, nil
+}
+
+// Path returns the location of the journal file
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Existed reports whether the journal file existed when it was opened, as
+// opposed to a new journal being started
+func (j *Journal) Existed() bool {
+	return j.existed
+}
+
+// Delivered reports whether the club's email has already been sent
+func (j *Journal) Delivered(club string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry, ok := j.doc.Clubs[club]
+	return ok && entry.Status == StatusSent
+}
+
+// Entries returns all recorded entries ordered by club name
+func (j *Journal) Entries() []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries := make([]Entry, 0, len(j.doc.Clubs))
+	for _, entry := range j.doc.Clubs {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].Club < entries[b].Club
+	})
+	return entries
+}
+
+// Record stores the outcome of a club and persists the journal
+func (j *Journal) Record(entry Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if previous, ok := j.doc.Clubs[entry.Club]; ok {
+		entry.Attempts = previous.Attempts
+	}
+	if entry.Status != StatusUnsent {
+		entry.Attempts++
+	}
+	if entry.UpdatedAt.IsZero() {
+		entry.UpdatedAt = time.Now()
+	}
+	j.doc.Clubs[entry.Club] = &entry
+
+	return j.save()
+}
+
+// save writes the journal to a temporary file and renames it into place so a
+// crash never leaves a truncated journal behind
+func (j *Journal) save() error {
+	content, err := json.MarshalIndent(j.doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode journal: %w", err)
+	}
+
+	tmpPath := j.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return fmt.Errorf("failed to replace journal: %w", err)
+	}
+	return nil
+}
+
+// HashFile returns the hex encoded SHA-256 hash of a file's content
+func HashFile(fileName string) (string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to hash file: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
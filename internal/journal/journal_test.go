@@ -0,0 +1,142 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"coral.daniel-guo.com/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey() Key {
+	return NewKey("0123456789abcdef0123456789abcdef", "pif", model.NewMonthPeriod(2025, time.May))
+}
+
+func TestNewKey(t *testing.T) {
+	key := testKey()
+
+	assert.Equal(t, "PIF", key.TransferType)
+	assert.Equal(t, "2025-05", key.Period)
+	assert.Equal(t, "pif_2025-05_0123456789abcdef.json", key.fileName())
+}
+
+func TestJournal_RecordAndReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	j, err := Open(dir, testKey())
+	require.NoError(t, err)
+	assert.False(t, j.Existed())
+	assert.False(t, j.Delivered("CLUB A"))
+
+	require.NoError(t, j.Record(Entry{Club: "CLUB A", Status: StatusFailed, Error: "throttled"}))
+	require.NoError(t, j.Record(Entry{Club: "CLUB B", Status: StatusSent, MessageID: "id-b"}))
+	require.NoError(t, j.Record(Entry{Club: "CLUB A", Status: StatusSent, MessageID: "id-a"}))
+
+	reopened, err := Open(dir, testKey())
+	require.NoError(t, err)
+
+	assert.True(t, reopened.Existed())
+	assert.True(t, reopened.Delivered("CLUB A"))
+	assert.True(t, reopened.Delivered("CLUB B"))
+	assert.False(t, reopened.Delivered("CLUB C"))
+
+	entries := reopened.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "CLUB A", entries[0].Club)
+	assert.Equal(t, 2, entries[0].Attempts)
+	assert.Equal(t, "id-a", entries[0].MessageID)
+	assert.Empty(t, entries[0].Error)
+	assert.Equal(t, 1, entries[1].Attempts)
+	assert.False(t, entries[1].UpdatedAt.IsZero())
+
+	_, err = os.Stat(reopened.Path() + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestJournal_RecordUnsent(t *testing.T) {
+	j, err := Open(t.TempDir(), testKey())
+	require.NoError(t, err)
+
+	require.NoError(t, j.Record(Entry{Club: "CLUB A", Status: StatusUnsent}))
+
+	entries := j.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, StatusUnsent, entries[0].Status)
+	assert.Equal(t, 0, entries[0].Attempts)
+	assert.False(t, j.Delivered("CLUB A"))
+}
+
+func TestJournal_SeparatePerRun(t *testing.T) {
+	dir := t.TempDir()
+
+	j, err := Open(dir, testKey())
+	require.NoError(t, err)
+	require.NoError(t, j.Record(Entry{Club: "CLUB A", Status: StatusSent}))
+
+	otherKey := NewKey(testKey().FileHash, "PIF", model.NewMonthPeriod(2025, time.June))
+	other, err := Open(dir, otherKey)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, j.Path(), other.Path())
+	assert.False(t, other.Delivered("CLUB A"))
+}
+
+func TestJournal_OpenErrors(t *testing.T) {
+	t.Run("corrupt journal", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, testKey().fileName())
+		require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o644))
+
+		_, err := Open(dir, testKey())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse journal")
+	})
+
+	t.Run("key mismatch", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, testKey().fileName())
+		content := `{"key":{"file_hash":"other","transfer_type":"PIF","period":"2025-05"},"clubs":{}}`
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		_, err := Open(dir, testKey())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "belongs to a different run")
+	})
+}
+
+func TestJournal_ConcurrentRecord(t *testing.T) {
+	j, err := Open(t.TempDir(), testKey())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for _, club := range []string{"A", "B", "C", "D", "E"} {
+		wg.Add(1)
+		go func(club string) {
+			defer wg.Done()
+			assert.NoError(t, j.Record(Entry{Club: club, Status: StatusSent}))
+		}(club)
+	}
+	wg.Wait()
+
+	assert.Len(t, j.Entries(), 5)
+}
+
+func TestHashFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "input.csv")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0o644))
+
+	hash, err := HashFile(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", hash)
+
+	_, err = HashFile(filepath.Join(dir, "missing.csv"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+// Package locations matches the club names of input files to location names
+package locations
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Normalize returns the form club and location names are compared in:
+// upper case, with punctuation treated as whitespace and runs of whitespace
+// collapsed, so "St. Kilda" and "ST  KILDA" are the same name
+func Normalize(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
+
+// Aliases maps the normalised club names of input files to the names of the
+// locations they stand for
+type Aliases map[string]string
+
+// LoadAliases reads an alias file from YAML or JSON, chosen by its extension,
+// mapping club names as they appear in input files to location names
+func LoadAliases(path string) (Aliases, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read location aliases: %w", err)
+	}
+
+	var names map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, &names)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &names)
+	default:
+		return nil, fmt.Errorf("unsupported location aliases file %s, expected .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse location aliases %s: %w", path, err)
+	}
+
+	aliases := make(Aliases, len(names))
+	for club, location := range names {
+		if err := aliases.Add(club, location); err != nil {
+			return nil, fmt.Errorf("invalid location aliases %s: %w", path, err)
+		}
+	}
+	return aliases, nil
+}
+
+// Save writes the aliases to a YAML or JSON file, chosen by its extension.
+// Club names are written normalised.
+func (a Aliases) Save(path string) error {
+	var content []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		content, err = json.MarshalIndent(map[string]string(a), "", "  ")
+		content = append(content, '\n')
+	case ".yaml", ".yml":
+		content, err = yaml.Marshal(map[string]string(a))
+	default:
+		return fmt.Errorf("unsupported location aliases file %s, expected .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode location aliases: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create location aliases directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write location aliases: %w", err)
+	}
+	return nil
+}
+
+// Add maps a club name to a location name
+func (a Aliases) Add(club, location string) error {
+	key := Normalize(club)
+	if key == "" || strings.TrimSpace(location) == "" {
+		return fmt.Errorf("alias %q to %q: club and location names must not be empty", club, location)
+	}
+	if existing, ok := a[key]; ok && Normalize(existing) != Normalize(location) {
+		return fmt.Errorf("club %s is mapped to both %s and %s", club, existing, location)
+	}
+	a.Set(club, location)
+	return nil
+}
+
+// Set maps a club name to a location name, replacing any earlier mapping
+func (a Aliases) Set(club, location string) {
+	a[Normalize(club)] = strings.TrimSpace(location)
+}
+
+// Resolve returns the location name for a club: the name it is mapped to,
+// or the club name itself
+func (a Aliases) Resolve(club string) string {
+	if location, ok := a[Normalize(club)]; ok {
+		return location
+	}
+	return club
+}
@@ -0,0 +1,103 @@
+package locations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Sydney CBD", "SYDNEY CBD"},
+		{"St. Kilda", "ST KILDA"},
+		{"  st   kilda ", "ST KILDA"},
+		{"Bondi-Junction", "BONDI JUNCTION"},
+		{"Club 24/7", "CLUB 24 7"},
+		{"Café Côte", "CAFÉ CÔTE"},
+		{" .. ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Normalize(tt.name))
+		})
+	}
+}
+
+func TestLoadAliases(t *testing.T) {
+	write := func(t *testing.T, name, content string) string {
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	t.Run("should load YAML aliases", func(t *testing.T) {
+		aliases, err := LoadAliases(write(t, "aliases.yaml", "St Kilda Beach: St. Kilda\nCBD: Sydney CBD\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "St. Kilda", aliases.Resolve("ST KILDA BEACH"))
+		assert.Equal(t, "Sydney CBD", aliases.Resolve("cbd"))
+		assert.Equal(t, "BONDI", aliases.Resolve("BONDI"))
+	})
+
+	t.Run("should load JSON aliases", func(t *testing.T) {
+		aliases, err := LoadAliases(write(t, "aliases.json", `{"CBD": "Sydney CBD"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "Sydney CBD", aliases.Resolve(" Cbd "))
+	})
+
+	t.Run("should reject invalid files", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			file     string
+			content  string
+			expected string
+		}{
+			{"unknown extension", "aliases.txt", "", "unsupported location aliases file"},
+			{"unparseable", "aliases.json", "[", "failed to parse location aliases"},
+			{"empty location", "aliases.yaml", "CBD: ''\n", "must not be empty"},
+			{"conflicting", "aliases.yaml", "CBD: Sydney CBD\ncbd: Bondi\n", "is mapped to both"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := LoadAliases(write(t, tt.file, tt.content))
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expected)
+			})
+		}
+	})
+
+	t.Run("should fail for a missing file", func(t *testing.T) {
+		_, err := LoadAliases(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Error(t, err)
+	})
+}
+
+func TestAliasesSave(t *testing.T) {
+	for _, name := range []string{"aliases.yaml", "aliases.json"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config", name)
+			aliases := Aliases{}
+			require.NoError(t, aliases.Add("St Kilda Beach", "St. Kilda"))
+			require.NoError(t, aliases.Add("cbd", "Sydney CBD"))
+
+			require.NoError(t, aliases.Save(path))
+
+			loaded, err := LoadAliases(path)
+			require.NoError(t, err)
+			assert.Equal(t, aliases, loaded)
+		})
+	}
+
+	t.Run("should reject an unknown extension", func(t *testing.T) {
+		err := Aliases{}.Save(filepath.Join(t.TempDir(), "aliases.txt"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported location aliases file")
+	})
+}
@@ -0,0 +1,106 @@
+package locations
+
+import (
+	"sort"
+	"strings"
+)
+
+// minSimilarity is the similarity a location name needs to be suggested for a club
+const minSimilarity = 0.5
+
+// Suggest returns up to limit of the candidate names closest to name, most
+// similar first. Names are compared normalised, by edit distance and by the
+// words they share, and candidates less than half similar are left out.
+func Suggest(name string, candidates []string, limit int) []string {
+	target := Normalize(name)
+	if target == "" || limit <= 0 {
+		return nil
+	}
+
+	type match struct {
+		name  string
+		score float64
+	}
+	var matches []match
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		normalized := Normalize(candidate)
+		if normalized == "" || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		if score := similarity(target, normalized); score >= minSimilarity {
+			matches = append(matches, match{name: candidate, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].name < matches[j].name
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	var names []string
+	for _, m := range matches {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// similarity scores two normalised names from 0 to 1 by the better of their
+// edit distance and the share of words they have in common
+func similarity(a, b string) float64 {
+	ar, br := []rune(a), []rune(b)
+	longest := max(len(ar), len(br))
+	if longest == 0 {
+		return 1
+	}
+	edit := 1 - float64(levenshtein(ar, br))/float64(longest)
+	return max(edit, tokenSimilarity(a, b))
+}
+
+// levenshtein returns the number of single rune insertions, deletions and
+// substitutions that turn a into b
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// tokenSimilarity returns the share of the distinct words of a and b that both contain
+func tokenSimilarity(a, b string) float64 {
+	words := make(map[string]int)
+	for _, word := range strings.Fields(a) {
+		words[word] |= 1
+	}
+	for _, word := range strings.Fields(b) {
+		words[word] |= 2
+	}
+	if len(words) == 0 {
+		return 0
+	}
+	shared := 0
+	for _, in := range words {
+		if in == 3 {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(words))
+}
@@ -0,0 +1,39 @@
+package locations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"St. Kilda", "Sydney CBD", "Sydney Olympic Park", "Bondi Junction", "Brunswick", "St Kilda"}
+
+	tests := []struct {
+		name     string
+		club     string
+		limit    int
+		expected []string
+	}{
+		{"misspelt", "ST KILDAA", 3, []string{"St. Kilda"}},
+		{"shared words", "CBD SYDNEY", 3, []string{"Sydney CBD"}},
+		{"closest first", "SYDNEY PARK", 3, []string{"Sydney Olympic Park", "Sydney CBD"}},
+		{"limited", "SYDNEY PARK", 1, []string{"Sydney Olympic Park"}},
+		{"typo", "BONDI JUNCTON", 3, []string{"Bondi Junction"}},
+		{"nothing close", "MELBOURNE CENTRAL", 3, nil},
+		{"empty name", " ", 3, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Suggest(tt.club, candidates, tt.limit))
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein([]rune("KILDA"), []rune("KILDA")))
+	assert.Equal(t, 1, levenshtein([]rune("KILDA"), []rune("KILDAA")))
+	assert.Equal(t, 3, levenshtein([]rune("KITTEN"), []rune("SITTING")))
+	assert.Equal(t, 4, levenshtein([]rune(""), []rune("CAFÉ")))
+}
@@ -1,7 +1,9 @@
 // Package model contains the domain data structures
 package model
 
-import "time"
+import (
+	"time"
+)
 
 // Location represents a club location with its contact information
 type Location struct {
@@ -19,17 +21,21 @@ type ClubTransferRow struct {
 	MembershipType string `csv:"Membership Type"`
 	HomeClub       string `csv:"Home Club"`
 	TargetClub     string `csv:"Target Club"`
+
+	// Line is the line of the input file the row starts on (the sheet row
+	// for XLSX input)
+	Line int `csv:"-"`
 }
 
 // ClubTransferData represents processed transfer data ready for output
 type ClubTransferData struct {
-	MemberID       string
-	FobNumber      string
-	FirstName      string
-	LastName       string
-	MembershipType string
-	HomeClub       string
-	TargetClub     string
-	TransferType   string
-	TransferDate   time.Time
+	MemberID       string    `csv:"Member Id"`
+	FobNumber      string    `csv:"Fob Number"`
+	FirstName      string    `csv:"First Name"`
+	LastName       string    `csv:"Last Name"`
+	MembershipType string    `csv:"Membership Type"`
+	HomeClub       string    `csv:"Home Club"`
+	TargetClub     string    `csv:"Target Club"`
+	TransferType   string    `csv:"Transfer Type"`
+	TransferDate   time.Time `csv:"Transfer Date"`
 }
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Period represents a reporting period spanning one or more whole months
+type Period struct {
+	// Start is the first day of the first month in the period
+	Start time.Time
+	// End is the first day of the last month in the period
+	End time.Time
+}
+
+// NewMonthPeriod returns the period covering the given month
+func NewMonthPeriod(year int, month time.Month) Period {
+	start := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return Period{Start: start, End: start}
+}
+
+// LastMonth returns the period covering the month before now
+func LastMonth(now time.Time) Period {
+	last := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	return NewMonthPeriod(last.Year(), last.Month())
+}
+
+// LastQuarter returns the three months ending with the month before now
+func LastQuarter(now time.Time) Period {
+	end := LastMonth(now).Start
+	return Period{Start: end.AddDate(0, -2, 0), End: end}
+}
+
+// String returns a human readable label such as "May 2025" or "March - May 2025"
+func (p Period) String() string {
+	switch {
+	case p.Start.Year() == p.End.Year() && p.Start.Month() == p.End.Month():
+		return fmt.Sprintf("%s %d", p.End.Month(), p.End.Year())
+	case p.Start.Year() == p.End.Year():
+		return fmt.Sprintf("%s - %s %d", p.Start.Month(), p.End.Month(), p.End.Year())
+	default:
+		return fmt.Sprintf("%s %d - %s %d", p.Start.Month(), p.Start.Year(), p.End.Month(), p.End.Year())
+	}
+}
+
+// NewQuarterPeriod returns the period covering the given calendar quarter (1-4)
+func NewQuarterPeriod(year, quarter int) Period {
+	start := time.Date(year, time.Month((quarter-1)*3+1), 1, 0, 0, 0, 0, time.UTC)
+	return Period{Start: start, End: start.AddDate(0, 2, 0)}
+}
+
+// ParsePeriod parses a month such as "2025-05" or a quarter such as "2025-Q2"
+func ParsePeriod(value string) (Period, error) {
+	var year, quarter int
+	if n, err := fmt.Sscanf(strings.ToUpper(value), "%4d-Q%1d", &year, &quarter); err == nil && n == 2 {
+		if quarter < 1 || quarter > 4 || len(value) != len("2006-Q1") {
+			return Period{}, fmt.Errorf("invalid period %q: quarter must be Q1-Q4", value)
+		}
+		return NewQuarterPeriod(year, quarter), nil
+	}
+
+	month, err := time.Parse("2006-01", value)
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid period %q: expected YYYY-MM or YYYY-QN", value)
+	}
+	return NewMonthPeriod(month.Year(), month.Month()), nil
+}
+
+// IsZero reports whether the period is unset
+func (p Period) IsZero() bool {
+	return p.Start.IsZero() && p.End.IsZero()
+}
+
+// Code returns a compact identifier for the period such as "2025-05",
+// "2025-Q2" for calendar quarters or "2025-03_2025-05" otherwise
+func (p Period) Code() string {
+	switch {
+	case p.Start.Equal(p.End):
+		return p.Start.Format("2006-01")
+	case p.Start.Year() == p.End.Year() && p.Start.Month()%3 == 1 && p.End.Month() == p.Start.Month()+2:
+		return fmt.Sprintf("%d-Q%d", p.Start.Year(), (p.Start.Month()-1)/3+1)
+	default:
+		return p.Start.Format("2006-01") + "_" + p.End.Format("2006-01")
+	}
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastMonth(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected string
+	}{
+		{
+			name:     "middle of year",
+			now:      time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC),
+			expected: "May 2025",
+		},
+		{
+			name:     "january rolls back to previous year",
+			now:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			expected: "December 2024",
+		},
+		{
+			name:     "end of a long month",
+			now:      time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC),
+			expected: "February 2025",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period := LastMonth(tt.now)
+			assert.Equal(t, tt.expected, period.String())
+			assert.Equal(t, period.Start, period.End)
+		})
+	}
+}
+
+func TestLastQuarter(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected string
+	}{
+		{
+			name:     "within one year",
+			now:      time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC),
+			expected: "March - May 2025",
+		},
+		{
+			name:     "spanning two years",
+			now:      time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+			expected: "November 2024 - January 2025",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, LastQuarter(tt.now).String())
+		})
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    Period
+		label       string
+		expectedErr string
+	}{
+		{
+			name:     "month",
+			value:    "2025-05",
+			expected: NewMonthPeriod(2025, time.May),
+			label:    "May 2025",
+		},
+		{
+			name:  "quarter",
+			value: "2025-Q2",
+			expected: Period{
+				Start: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			},
+			label: "April - June 2025",
+		},
+		{
+			name:     "lower case quarter",
+			value:    "2024-q4",
+			expected: NewQuarterPeriod(2024, 4),
+			label:    "October - December 2024",
+		},
+		{
+			name:        "invalid quarter",
+			value:       "2025-Q5",
+			expectedErr: "quarter must be Q1-Q4",
+		},
+		{
+			name:        "invalid month",
+			value:       "2025-13",
+			expectedErr: "expected YYYY-MM or YYYY-QN",
+		},
+		{
+			name:        "garbage",
+			value:       "last month",
+			expectedErr: "expected YYYY-MM or YYYY-QN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period, err := ParsePeriod(tt.value)
+
+			if tt.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.True(t, period.IsZero())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, period)
+			assert.Equal(t, tt.label, period.String())
+			assert.False(t, period.IsZero())
+		})
+	}
+}
+
+func TestPeriodCode(t *testing.T) {
+	assert.Equal(t, "2025-05", NewMonthPeriod(2025, time.May).Code())
+	assert.Equal(t, "2025-Q2", NewQuarterPeriod(2025, 2).Code())
+	assert.Equal(t, "2025-03_2025-05", LastQuarter(time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)).Code())
+	assert.Equal(t, "2024-11_2025-01", LastQuarter(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)).Code())
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// Recipients are the addresses a club's email is delivered to
+type Recipients struct {
+	To  []string
+	Cc  []string
+	Bcc []string
+}
+
+// All returns every address the message is delivered to, without duplicates
+func (r Recipients) All() []string {
+	seen := make(map[string]bool)
+	var all []string
+	for _, list := range [][]string{r.To, r.Cc, r.Bcc} {
+		for _, address := range list {
+			key := strings.ToLower(address)
+			if !seen[key] {
+				seen[key] = true
+				all = append(all, address)
+			}
+		}
+	}
+	return all
+}
+
+// IsEmpty reports whether there are no recipients at all
+func (r Recipients) IsEmpty() bool {
+	return len(r.To) == 0 && len(r.Cc) == 0 && len(r.Bcc) == 0
+}
+
+// ParseRecipients parses a list of addresses separated by commas or
+// semicolons. Each address is a To recipient unless prefixed with "cc:" or
+// "bcc:", e.g. "manager@club.com; cc:frontdesk@club.com".
+func ParseRecipients(list string) (Recipients, error) {
+	var recipients Recipients
+	fields := strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ';'
+	})
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		target := &recipients.To
+		lower := strings.ToLower(field)
+		switch {
+		case strings.HasPrefix(lower, "cc:"):
+			target, field = &recipients.Cc, field[len("cc:"):]
+		case strings.HasPrefix(lower, "bcc:"):
+			target, field = &recipients.Bcc, field[len("bcc:"):]
+		case strings.HasPrefix(lower, "to:"):
+			field = field[len("to:"):]
+		}
+
+		address, err := mail.ParseAddress(strings.TrimSpace(field))
+		if err != nil {
+			return Recipients{}, fmt.Errorf("invalid email address %q", strings.TrimSpace(field))
+		}
+		*target = append(*target, address.Address)
+	}
+	return recipients, nil
+}
+
+// Recipients parses the location's email column into its recipients
+func (l *Location) Recipients() (Recipients, error) {
+	return ParseRecipients(l.Email)
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRecipients(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Recipients
+		wantErr  bool
+	}{
+		{
+			name:     "single address",
+			input:    "club@example.com",
+			expected: Recipients{To: []string{"club@example.com"}},
+		},
+		{
+			name:     "empty",
+			input:    "  ",
+			expected: Recipients{},
+		},
+		{
+			name:  "comma and semicolon separated with prefixes",
+			input: "manager@example.com, frontdesk@example.com; cc:owner@example.com;BCC: audit@example.com",
+			expected: Recipients{
+				To:  []string{"manager@example.com", "frontdesk@example.com"},
+				Cc:  []string{"owner@example.com"},
+				Bcc: []string{"audit@example.com"},
+			},
+		},
+		{
+			name:     "display names and explicit to",
+			input:    "to:Club Manager <manager@example.com>,;",
+			expected: Recipients{To: []string{"manager@example.com"}},
+		},
+		{
+			name:    "invalid address",
+			input:   "manager@example.com, not-an-address",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipients, err := ParseRecipients(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "not-an-address")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, recipients)
+		})
+	}
+}
+
+func TestRecipients_All(t *testing.T) {
+	recipients := Recipients{
+		To:  []string{"a@example.com", "b@example.com"},
+		Cc:  []string{"A@example.com", "c@example.com"},
+		Bcc: []string{"d@example.com"},
+	}
+
+	assert.Equal(t, []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"}, recipients.All())
+	assert.False(t, recipients.IsEmpty())
+	assert.True(t, Recipients{}.IsEmpty())
+}
+
+func TestLocation_Recipients(t *testing.T) {
+	location := &Location{Email: "club@example.com; cc:owner@example.com"}
+
+	recipients, err := location.Recipients()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"club@example.com"}, recipients.To)
+	assert.Equal(t, []string{"owner@example.com"}, recipients.Cc)
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a manually advanced clock
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestLimiter(rate float64, burst int) (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := NewLimiter(rate, burst)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestNewLimiter_DerivesBurstFromRate(t *testing.T) {
+	assert.Equal(t, 14, NewLimiter(14, 0).Burst())
+	assert.Equal(t, 1, NewLimiter(0.5, 0).Burst())
+	assert.Equal(t, 3, NewLimiter(14, 3).Burst())
+	assert.Equal(t, 14.0, NewLimiter(14, 0).Rate())
+}
+
+func TestLimiter_Reserve(t *testing.T) {
+	limiter, clock := newTestLimiter(2, 2)
+
+	// The burst is available immediately
+	assert.Zero(t, limiter.reserve())
+	assert.Zero(t, limiter.reserve())
+
+	// Further tokens arrive at the configured rate
+	assert.Equal(t, 500*time.Millisecond, limiter.reserve())
+	assert.Equal(t, time.Second, limiter.reserve())
+
+	// Refilling never exceeds the burst
+	clock.Advance(10 * time.Second)
+	assert.Zero(t, limiter.reserve())
+	assert.Zero(t, limiter.reserve())
+	assert.Equal(t, 500*time.Millisecond, limiter.reserve())
+}
+
+func TestLimiter_WaitUnlimited(t *testing.T) {
+	var nilLimiter *Limiter
+	assert.NoError(t, nilLimiter.Wait(context.Background()))
+
+	limiter := NewLimiter(0, 0)
+	for range 100 {
+		assert.NoError(t, limiter.Wait(context.Background()))
+	}
+}
+
+func TestLimiter_WaitThrottles(t *testing.T) {
+	limiter := NewLimiter(100, 1)
+
+	start := time.Now()
+	for range 4 {
+		assert.NoError(t, limiter.Wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 25*time.Millisecond)
+}
+
+func TestLimiter_WaitCancelled(t *testing.T) {
+	limiter := NewLimiter(0.001, 1)
+	assert.NoError(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+
+	// The cancelled reservation is handed back
+	assert.InDelta(t, 0, limiter.tokens, 0.01)
+}
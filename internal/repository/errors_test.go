@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, true},
+		{"too many connections", &pgconn.PgError{Code: "53300"}, true},
+		{"cannot connect now", &pgconn.PgError{Code: "57P03"}, true},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"wrapped connection failure", fmt.Errorf("query: %w", &pgconn.PgError{Code: "08001"}), true},
+		{"undefined table", &pgconn.PgError{Code: "42P01"}, false},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, false},
+		{"network", &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, true},
+		{"no rows", pgx.ErrNoRows, false},
+		{"cancelled", context.Canceled, false},
+		{"plain", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsRetryable(tt.err))
+		})
+	}
+}
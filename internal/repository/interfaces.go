@@ -18,5 +18,7 @@ type PoolInterface interface {
 
 // LocationRepositoryInterface defines the interface for location repository operations
 type LocationRepositoryInterface interface {
-	FindByName(name string) (*model.Location, error)
+	FindByName(ctx context.Context, name string) (*model.Location, error)
+	FindByNames(ctx context.Context, names []string) (map[string]*model.Location, error)
+	FindAll(ctx context.Context) ([]*model.Location, error)
 }
@@ -5,11 +5,16 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"strings"
 
+	"coral.daniel-guo.com/internal/locations"
+	"coral.daniel-guo.com/internal/logger"
 	"coral.daniel-guo.com/internal/model"
 )
 
+// normalizedName normalises the location name column in SQL the way
+// locations.Normalize does in Go
+const normalizedName = `UPPER(TRIM(REGEXP_REPLACE(name, '[^[:alnum:]]+', ' ', 'g')))`
+
 // LocationRepository provides data access for locations
 type LocationRepository struct {
 	db PoolInterface
@@ -20,21 +25,20 @@ func NewLocationRepository(db PoolInterface) *LocationRepository {
 	return &LocationRepository{db: db}
 }
 
-// FindByName looks up a location by its name
-func (r *LocationRepository) FindByName(name string) (*model.Location, error) {
-	ctx := context.Background()
-	trimmedName := strings.TrimSpace(name)
+// FindByName looks up a location by its name, ignoring case, punctuation and whitespace
+func (r *LocationRepository) FindByName(ctx context.Context, name string) (*model.Location, error) {
+	normalized := locations.Normalize(name)
 
 	query := `
 		SELECT id, name, email
 		FROM location
-		WHERE TRIM(name) = $1
+		WHERE ` + normalizedName + ` = $1
 	`
 
 	var location model.Location
 	var email sql.NullString
 
-	err := r.db.QueryRow(ctx, query, trimmedName).Scan(&location.ID, &location.Name, &email)
+	err := r.db.QueryRow(ctx, query, normalized).Scan(&location.ID, &location.Name, &email)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -48,3 +52,82 @@ func (r *LocationRepository) FindByName(name string) (*model.Location, error) {
 
 	return &location, nil
 }
+
+// FindByNames looks up the locations for several names in a single query,
+// ignoring case, punctuation and whitespace. The result is keyed by the
+// normalised name (see locations.Normalize); names without a location are absent.
+func (r *LocationRepository) FindByNames(ctx context.Context, names []string) (map[string]*model.Location, error) {
+	normalizedNames := make([]string, 0, len(names))
+	for _, name := range names {
+		normalizedNames = append(normalizedNames, locations.Normalize(name))
+	}
+
+	query := `
+		SELECT id, name, email
+		FROM location
+		WHERE ` + normalizedName + ` = ANY($1)
+	`
+
+	rows, err := r.db.Query(ctx, query, normalizedNames)
+	if err != nil {
+		return nil, fmt.Errorf("error querying locations by name: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]*model.Location, len(names))
+	for rows.Next() {
+		var location model.Location
+		var email sql.NullString
+		if err := rows.Scan(&location.ID, &location.Name, &email); err != nil {
+			return nil, fmt.Errorf("error scanning location: %w", err)
+		}
+		if email.Valid {
+			location.Email = email.String
+		}
+
+		key := locations.Normalize(location.Name)
+		if existing, ok := found[key]; ok {
+			logger.Warn("Multiple locations named %s, using %s and ignoring %s", key, existing.ID, location.ID)
+			continue
+		}
+		found[key] = &location
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading locations: %w", err)
+	}
+
+	return found, nil
+}
+
+// FindAll returns every location, ordered by name
+func (r *LocationRepository) FindAll(ctx context.Context) ([]*model.Location, error) {
+	query := `
+		SELECT id, name, email
+		FROM location
+		ORDER BY name
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying locations: %w", err)
+	}
+	defer rows.Close()
+
+	var all []*model.Location
+	for rows.Next() {
+		var location model.Location
+		var email sql.NullString
+		if err := rows.Scan(&location.ID, &location.Name, &email); err != nil {
+			return nil, fmt.Errorf("error scanning location: %w", err)
+		}
+		if email.Valid {
+			location.Email = email.String
+		}
+		all = append(all, &location)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading locations: %w", err)
+	}
+
+	return all, nil
+}
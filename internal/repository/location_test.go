@@ -4,10 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"testing"
 
 	"coral.daniel-guo.com/internal/model"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -69,7 +71,7 @@ func TestLocationRepository_FindByName(t *testing.T) {
 		{
 			name:         "successful find with email",
 			locationName: "Test Club",
-			expectedName: "Test Club",
+			expectedName: "TEST CLUB",
 			setupMock: func(pool *MockPool, row *MockRow, expectedName string) {
 				pool.On("QueryRow", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(args []interface{}) bool {
 					return len(args) == 1 && args[0] == expectedName
@@ -94,7 +96,7 @@ func TestLocationRepository_FindByName(t *testing.T) {
 		{
 			name:         "successful find without email",
 			locationName: "Test Club",
-			expectedName: "Test Club",
+			expectedName: "TEST CLUB",
 			setupMock: func(pool *MockPool, row *MockRow, expectedName string) {
 				pool.On("QueryRow", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(args []interface{}) bool {
 					return len(args) == 1 && args[0] == expectedName
@@ -118,7 +120,7 @@ func TestLocationRepository_FindByName(t *testing.T) {
 		{
 			name:         "location not found",
 			locationName: "Nonexistent Club",
-			expectedName: "Nonexistent Club",
+			expectedName: "NONEXISTENT CLUB",
 			setupMock: func(pool *MockPool, row *MockRow, expectedName string) {
 				pool.On("QueryRow", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(args []interface{}) bool {
 					return len(args) == 1 && args[0] == expectedName
@@ -130,7 +132,7 @@ func TestLocationRepository_FindByName(t *testing.T) {
 		{
 			name:         "database error",
 			locationName: "Test Club",
-			expectedName: "Test Club",
+			expectedName: "TEST CLUB",
 			setupMock: func(pool *MockPool, row *MockRow, expectedName string) {
 				pool.On("QueryRow", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(args []interface{}) bool {
 					return len(args) == 1 && args[0] == expectedName
@@ -139,10 +141,22 @@ func TestLocationRepository_FindByName(t *testing.T) {
 			},
 			expectedError: "error querying location by name",
 		},
+		{
+			name:         "should ignore punctuation in name",
+			locationName: "St. Kilda",
+			expectedName: "ST KILDA",
+			setupMock: func(pool *MockPool, row *MockRow, expectedName string) {
+				pool.On("QueryRow", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(args []interface{}) bool {
+					return len(args) == 1 && args[0] == expectedName
+				})).Return(row)
+				row.On("Scan", mock.Anything, mock.Anything, mock.Anything).Return(sql.ErrNoRows)
+			},
+			expectedResult: nil,
+		},
 		{
 			name:         "should trim whitespace from name",
 			locationName: "  Test Club  ",
-			expectedName: "Test Club", // Should be trimmed
+			expectedName: "TEST CLUB", // Should be trimmed and upper-cased
 			setupMock: func(pool *MockPool, row *MockRow, expectedName string) {
 				// Should call with trimmed name
 				pool.On("QueryRow", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(args []interface{}) bool {
@@ -174,7 +188,7 @@ func TestLocationRepository_FindByName(t *testing.T) {
 
 			tt.setupMock(pool, row, tt.expectedName)
 
-			result, err := repo.FindByName(tt.locationName)
+			result, err := repo.FindByName(context.Background(), tt.locationName)
 
 			if tt.expectedError != "" {
 				require.Error(t, err)
@@ -190,3 +204,162 @@ func TestLocationRepository_FindByName(t *testing.T) {
 		})
 	}
 }
+
+// fakeRows implements pgx.Rows over a fixed set of location rows
+type fakeRows struct {
+	rows    [][3]any
+	index   int
+	err     error
+	scanErr error
+	closed  bool
+}
+
+func (r *fakeRows) Close()                                       { r.closed = true }
+func (r *fakeRows) Err() error                                   { return r.err }
+func (r *fakeRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (r *fakeRows) Values() ([]any, error)                       { return nil, nil }
+func (r *fakeRows) RawValues() [][]byte                          { return nil }
+func (r *fakeRows) Conn() *pgx.Conn                              { return nil }
+
+func (r *fakeRows) Next() bool {
+	if r.index >= len(r.rows) {
+		return false
+	}
+	r.index++
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	if r.scanErr != nil {
+		return r.scanErr
+	}
+	row := r.rows[r.index-1]
+	*dest[0].(*string) = row[0].(string)
+	*dest[1].(*string) = row[1].(string)
+	*dest[2].(*sql.NullString) = row[2].(sql.NullString)
+	return nil
+}
+
+func TestLocationRepository_FindByNames(t *testing.T) {
+	t.Run("should query all names at once and key by trimmed name", func(t *testing.T) {
+		pool := &MockPool{}
+		rows := &fakeRows{rows: [][3]any{
+			{"1", "CLUB A ", sql.NullString{String: "a@example.com", Valid: true}},
+			{"2", "CLUB B", sql.NullString{}},
+		}}
+		pool.On("Query", mock.Anything, mock.MatchedBy(func(query string) bool {
+			return strings.Contains(query, "= ANY($1)")
+		}), mock.MatchedBy(func(args []interface{}) bool {
+			names, ok := args[0].([]string)
+			return len(args) == 1 && ok && assert.ObjectsAreEqual([]string{"CLUB A", "CLUB B", "CLUB C"}, names)
+		})).Return(rows, nil)
+
+		repo := NewLocationRepository(pool)
+		result, err := repo.FindByNames(context.Background(), []string{" CLUB A", "CLUB B", "CLUB C"})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]*model.Location{
+			"CLUB A": {ID: "1", Name: "CLUB A ", Email: "a@example.com"},
+			"CLUB B": {ID: "2", Name: "CLUB B", Email: ""},
+		}, result)
+		assert.True(t, rows.closed)
+		pool.AssertExpectations(t)
+	})
+
+	t.Run("should match names ignoring case, punctuation and whitespace", func(t *testing.T) {
+		pool := &MockPool{}
+		rows := &fakeRows{rows: [][3]any{
+			{"1", "St. Kilda", sql.NullString{String: "stkilda@example.com", Valid: true}},
+			{"2", "Sydney  CBD", sql.NullString{String: "cbd@example.com", Valid: true}},
+		}}
+		pool.On("Query", mock.Anything, mock.MatchedBy(func(query string) bool {
+			return strings.Contains(query, normalizedName)
+		}), mock.MatchedBy(func(args []interface{}) bool {
+			names, ok := args[0].([]string)
+			return len(args) == 1 && ok && assert.ObjectsAreEqual([]string{"ST KILDA", "SYDNEY CBD"}, names)
+		})).Return(rows, nil)
+
+		result, err := NewLocationRepository(pool).FindByNames(context.Background(), []string{"ST KILDA", "Sydney CBD"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "1", result["ST KILDA"].ID)
+		assert.Equal(t, "2", result["SYDNEY CBD"].ID)
+		pool.AssertExpectations(t)
+	})
+
+	t.Run("should keep the first of duplicate names", func(t *testing.T) {
+		pool := &MockPool{}
+		rows := &fakeRows{rows: [][3]any{
+			{"1", "CLUB A", sql.NullString{String: "first@example.com", Valid: true}},
+			{"2", "CLUB A", sql.NullString{String: "second@example.com", Valid: true}},
+		}}
+		pool.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(rows, nil)
+
+		result, err := NewLocationRepository(pool).FindByNames(context.Background(), []string{"CLUB A"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "1", result["CLUB A"].ID)
+	})
+
+	t.Run("should return query error", func(t *testing.T) {
+		pool := &MockPool{}
+		pool.On("Query", mock.Anything, mock.Anything, mock.Anything).
+			Return((*fakeRows)(nil), errors.New("connection reset"))
+
+		_, err := NewLocationRepository(pool).FindByNames(context.Background(), []string{"CLUB A"})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error querying locations by name")
+	})
+
+	t.Run("should return scan and iteration errors", func(t *testing.T) {
+		pool := &MockPool{}
+		pool.On("Query", mock.Anything, mock.Anything, mock.Anything).
+			Return(&fakeRows{rows: [][3]any{{"1", "A", sql.NullString{}}}, scanErr: errors.New("bad type")}, nil).Once()
+		pool.On("Query", mock.Anything, mock.Anything, mock.Anything).
+			Return(&fakeRows{err: errors.New("stream closed")}, nil).Once()
+		repo := NewLocationRepository(pool)
+
+		_, err := repo.FindByNames(context.Background(), []string{"A"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error scanning location")
+
+		_, err = repo.FindByNames(context.Background(), []string{"A"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error reading locations")
+	})
+}
+
+func TestLocationRepository_FindAll(t *testing.T) {
+	t.Run("should return every location", func(t *testing.T) {
+		pool := &MockPool{}
+		rows := &fakeRows{rows: [][3]any{
+			{"1", "St. Kilda", sql.NullString{String: "stkilda@example.com", Valid: true}},
+			{"2", "Sydney CBD", sql.NullString{}},
+		}}
+		pool.On("Query", mock.Anything, mock.MatchedBy(func(query string) bool {
+			return strings.Contains(query, "ORDER BY name")
+		}), mock.Anything).Return(rows, nil)
+
+		result, err := NewLocationRepository(pool).FindAll(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, []*model.Location{
+			{ID: "1", Name: "St. Kilda", Email: "stkilda@example.com"},
+			{ID: "2", Name: "Sydney CBD"},
+		}, result)
+		assert.True(t, rows.closed)
+	})
+
+	t.Run("should return query errors", func(t *testing.T) {
+		pool := &MockPool{}
+		pool.On("Query", mock.Anything, mock.Anything, mock.Anything).
+			Return((*fakeRows)(nil), errors.New("connection reset"))
+
+		_, err := NewLocationRepository(pool).FindAll(context.Background())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error querying locations")
+	})
+}
@@ -33,8 +33,8 @@ type PoolConfig struct {
 }
 
 // NewPool creates a new database connection pool
-func NewPool(cfg PoolConfig) (*Pool, error) {
-	config, err := loadDBConfig(cfg.Environment, cfg.SecretsManager)
+func NewPool(ctx context.Context, cfg PoolConfig) (*Pool, error) {
+	config, err := loadDBConfig(ctx, cfg.Environment, cfg.SecretsManager)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load db config: %w", err)
 	}
@@ -42,12 +42,13 @@ func NewPool(cfg PoolConfig) (*Pool, error) {
 	connString := fmt.Sprintf("postgres://%s:%s@%s:%d/%s",
 		config.Username, config.Password, config.Host, config.Port, config.DBName)
 
-	dbPool, err := pgxpool.New(context.Background(), connString)
+	dbPool, err := pgxpool.New(ctx, connString)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create connection pool: %w", err)
 	}
 
-	if err := dbPool.Ping(context.Background()); err != nil {
+	if err := dbPool.Ping(ctx); err != nil {
+		dbPool.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
@@ -86,11 +87,11 @@ func (p *Pool) Exec(ctx context.Context, query string, args ...any) (int64, erro
 }
 
 // loadDBConfig loads database configuration from AWS Secrets Manager
-func loadDBConfig(env string, secretsManager *secrets.Manager) (*DBConfig, error) {
+func loadDBConfig(ctx context.Context, env string, secretsManager *secrets.Manager) (*DBConfig, error) {
 	secretName := fmt.Sprintf("hub-insights-rds-cluster-readonly-%s", env)
 	logger.Info("Loading database configuration from secret: %s", secretName)
 
-	secretData, err := secretsManager.GetSecret(secretName)
+	secretData, err := secretsManager.GetSecret(ctx, secretName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get secret from %s: %w", env, err)
 	}
@@ -0,0 +1,84 @@
+// Package retry runs operations with exponential backoff
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes how often and how quickly an operation is retried
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values below 1 are treated as a single attempt.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, doubled on every retry
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between two attempts (0 means no cap)
+	MaxDelay time.Duration
+
+	// Jitter is the fraction (0-1) of each delay that is randomised so
+	// concurrent workers do not retry in lockstep
+	Jitter float64
+}
+
+// DefaultPolicy returns a policy suitable for calls to AWS and the database
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// Classifier reports whether an error is transient and worth retrying
+type Classifier func(error) bool
+
+// Do calls fn until it succeeds, returns an error the classifier considers
+// permanent, the attempts are exhausted or ctx is done. The error of the
+// last attempt is returned.
+func Do(ctx context.Context, policy Policy, retryable Classifier, fn func(ctx context.Context) error) error {
+	attempts := max(policy.MaxAttempts, 1)
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if attempt >= attempts || retryable == nil || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(policy.Delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Delay returns the backoff before retrying after the given attempt (1-based)
+func (p Policy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 && delay > 0 {
+		jitter := min(p.Jitter, 1)
+		spread := float64(delay) * jitter
+		delay = time.Duration(float64(delay) - spread + rand.Float64()*2*spread)
+	}
+	return delay
+}
@@ -0,0 +1,102 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTransient = errors.New("transient")
+
+func isTransient(err error) bool {
+	return errors.Is(err, errTransient)
+}
+
+func fastPolicy(attempts int) Policy {
+	return Policy{MaxAttempts: attempts, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+}
+
+func TestDo_SucceedsAfterTransientErrors(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), fastPolicy(3), isTransient, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDo_StopsOnPermanentError(t *testing.T) {
+	permanent := errors.New("permanent")
+	calls := 0
+	err := Do(context.Background(), fastPolicy(5), isTransient, func(context.Context) error {
+		calls++
+		return permanent
+	})
+
+	assert.ErrorIs(t, err, permanent)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_ReturnsLastErrorWhenExhausted(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), fastPolicy(3), isTransient, func(context.Context) error {
+		calls++
+		return errTransient
+	})
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDo_ZeroAttemptsRunsOnce(t *testing.T) {
+	calls := 0
+	_ = Do(context.Background(), Policy{}, isTransient, func(context.Context) error {
+		calls++
+		return errTransient
+	})
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_StopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := Policy{MaxAttempts: 5, BaseDelay: time.Hour}
+
+	calls := 0
+	err := Do(ctx, policy, isTransient, func(context.Context) error {
+		calls++
+		cancel()
+		return errTransient
+	})
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 1, calls)
+}
+
+func TestPolicy_Delay(t *testing.T) {
+	policy := Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	assert.Equal(t, 100*time.Millisecond, policy.Delay(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Delay(2))
+	assert.Equal(t, 400*time.Millisecond, policy.Delay(3))
+	assert.Equal(t, time.Second, policy.Delay(5))
+	assert.Equal(t, time.Second, policy.Delay(50))
+}
+
+func TestPolicy_DelayJitter(t *testing.T) {
+	policy := Policy{BaseDelay: 100 * time.Millisecond, Jitter: 0.5}
+
+	for range 100 {
+		delay := policy.Delay(1)
+		assert.GreaterOrEqual(t, delay, 50*time.Millisecond)
+		assert.LessOrEqual(t, delay, 150*time.Millisecond)
+	}
+}
@@ -0,0 +1,226 @@
+// Package schema declares the columns of input and output files and how
+// they map onto the fields of the transfer models
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"coral.daniel-guo.com/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Column maps a file column onto a model field
+type Column struct {
+	// Name is the column header
+	Name string `yaml:"name" json:"name"`
+
+	// Field is the name of the model struct field the column holds
+	Field string `yaml:"field" json:"field"`
+
+	// Required input columns must be in the header row and filled in on every row
+	Required bool `yaml:"required,omitempty" json:"required,omitempty"`
+
+	// Aliases are other header names the input column may have
+	Aliases []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+}
+
+// Columns is an ordered list of columns
+type Columns []Column
+
+// Schema declares the columns of input files, mapped onto
+// model.ClubTransferRow, and of attachment files, mapped from
+// model.ClubTransferData in the order they appear
+type Schema struct {
+	Input  Columns `yaml:"input" json:"input"`
+	Output Columns `yaml:"output" json:"output"`
+}
+
+// requiredFields are the input fields every run depends on
+var requiredFields = []string{"MemberID", "HomeClub", "TargetClub"}
+
+var (
+	rowType  = reflect.TypeOf(model.ClubTransferRow{})
+	dataType = reflect.TypeOf(model.ClubTransferData{})
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// Default returns the schema described by the csv struct tags of the
+// models. Every input column is required.
+func Default() Schema {
+	input := tagColumns(rowType)
+	for i := range input {
+		input[i].Required = true
+	}
+	return Schema{Input: input, Output: tagColumns(dataType)}
+}
+
+// DefaultInput returns the input columns of the default schema
+func DefaultInput() Columns {
+	return Default().Input
+}
+
+// DefaultOutput returns the output columns of the default schema
+func DefaultOutput() Columns {
+	return Default().Output
+}
+
+// tagColumns returns a column for every field of t with a csv tag other than "-"
+func tagColumns(t reflect.Type) Columns {
+	var columns Columns
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name := field.Tag.Get("csv"); name != "" && name != "-" {
+			columns = append(columns, Column{Name: name, Field: field.Name})
+		}
+	}
+	return columns
+}
+
+// Load reads a schema from a YAML or JSON file, chosen by its extension.
+// A section left out of the file keeps its default columns.
+func Load(path string) (Schema, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Schema{}, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	var s Schema
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, &s)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &s)
+	default:
+		return Schema{}, fmt.Errorf("unsupported schema file %s, expected .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return Schema{}, fmt.Errorf("failed to parse schema %s: %w", path, err)
+	}
+
+	if len(s.Input) == 0 {
+		s.Input = DefaultInput()
+	}
+	if len(s.Output) == 0 {
+		s.Output = DefaultOutput()
+	}
+	if err := s.Validate(); err != nil {
+		return Schema{}, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Validate checks every column maps onto an existing model field and that
+// the fields every run depends on are required input columns
+func (s Schema) Validate() error {
+	if err := s.Input.validate("input", rowType); err != nil {
+		return err
+	}
+	if err := s.Output.validate("output", dataType); err != nil {
+		return err
+	}
+	for _, field := range requiredFields {
+		column, ok := s.Input.byField(field)
+		if !ok || !column.Required {
+			return fmt.Errorf("input field %s must be mapped by a required column", field)
+		}
+	}
+	return nil
+}
+
+// validate checks the columns have unique names and map onto distinct fields of t
+func (c Columns) validate(section string, t reflect.Type) error {
+	names := make(map[string]bool)
+	fields := make(map[string]bool)
+	for _, column := range c {
+		if strings.TrimSpace(column.Name) == "" {
+			return fmt.Errorf("%s column for field %s has no name", section, column.Field)
+		}
+		field, ok := t.FieldByName(column.Field)
+		if !ok || (field.Type.Kind() != reflect.String && field.Type != timeType) {
+			return fmt.Errorf("%s column %s: unknown field %q", section, column.Name, column.Field)
+		}
+		if names[strings.ToLower(column.Name)] {
+			return fmt.Errorf("%s column %s is declared twice", section, column.Name)
+		}
+		if fields[column.Field] {
+			return fmt.Errorf("%s field %s is mapped by more than one column", section, column.Field)
+		}
+		names[strings.ToLower(column.Name)] = true
+		fields[column.Field] = true
+	}
+	return nil
+}
+
+// Names returns the column names in order
+func (c Columns) Names() []string {
+	names := make([]string, len(c))
+	for i, column := range c {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// byField returns the column mapping the field
+func (c Columns) byField(field string) (Column, bool) {
+	for _, column := range c {
+		if column.Field == field {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// Validate checks a row against the input columns and returns the reasons
+// it is invalid, if any: a required column left empty
+func (c Columns) Validate(row model.ClubTransferRow) []string {
+	var reasons []string
+	var missing []string
+	for _, column := range c {
+		if column.Required && strings.TrimSpace(column.RowValue(row)) == "" {
+			missing = append(missing, column.Name)
+		}
+	}
+	if len(missing) > 0 {
+		reasons = append(reasons, "missing "+strings.Join(missing, ", "))
+	}
+	return reasons
+}
+
+// RowValue returns the value of the column's field in an input row
+func (c Column) RowValue(row model.ClubTransferRow) string {
+	field := reflect.ValueOf(row).FieldByName(c.Field)
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return ""
+	}
+	return field.String()
+}
+
+// SetRowValue sets the column's field in an input row
+func (c Column) SetRowValue(row *model.ClubTransferRow, value string) {
+	field := reflect.ValueOf(row).Elem().FieldByName(c.Field)
+	if field.IsValid() && field.Kind() == reflect.String {
+		field.SetString(value)
+	}
+}
+
+// DataValue returns the value of the column's field in transfer data, a
+// string or a time.Time
+func (c Column) DataValue(data model.ClubTransferData) interface{} {
+	field := reflect.ValueOf(data).FieldByName(c.Field)
+	if !field.IsValid() {
+		return ""
+	}
+	return field.Interface()
+}
+
+// IsDate reports whether the column holds a date field of the transfer data
+func (c Column) IsDate() bool {
+	field, ok := dataType.FieldByName(c.Field)
+	return ok && field.Type == timeType
+}
@@ -0,0 +1,135 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"coral.daniel-guo.com/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefault(t *testing.T) {
+	s := Default()
+
+	assert.Equal(t, []string{
+		"Member Id", "Fob Number", "First Name", "Last Name", "Membership Type", "Home Club", "Target Club",
+	}, s.Input.Names())
+	assert.Equal(t, Column{Name: "Member Id", Field: "MemberID", Required: true}, s.Input[0])
+	assert.Equal(t, []string{
+		"Member Id", "Fob Number", "First Name", "Last Name", "Membership Type", "Home Club", "Target Club",
+		"Transfer Type", "Transfer Date",
+	}, s.Output.Names())
+	assert.NoError(t, s.Validate())
+}
+
+func writeSchema(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("should load a YAML schema", func(t *testing.T) {
+		path := writeSchema(t, "schema.yaml", `
+input:
+  - {name: Member Number, field: MemberID, required: true}
+  - {name: Branch, field: HomeClub, required: true, aliases: [Home Branch]}
+  - {name: New Branch, field: TargetClub, required: true}
+  - {name: Fob, field: FobNumber}
+output:
+  - {name: Member, field: MemberID}
+  - {name: Date, field: TransferDate}
+`)
+		s, err := Load(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, Column{Name: "Branch", Field: "HomeClub", Required: true, Aliases: []string{"Home Branch"}},
+			s.Input[1])
+		assert.False(t, s.Input[3].Required)
+		assert.Equal(t, []string{"Member", "Date"}, s.Output.Names())
+	})
+
+	t.Run("should load a JSON schema and default missing sections", func(t *testing.T) {
+		path := writeSchema(t, "schema.json", `{"output": [{"name": "Member Id", "field": "MemberID"}]}`)
+		s, err := Load(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, DefaultInput(), s.Input)
+		assert.Equal(t, []string{"Member Id"}, s.Output.Names())
+	})
+
+	t.Run("should reject invalid schemas", func(t *testing.T) {
+		tests := []struct {
+			content string
+			err     string
+		}{
+			{`output: [{name: Member, field: Nickname}]`, `output column Member: unknown field "Nickname"`},
+			{`output: [{name: Line, field: Line}]`, `output column Line: unknown field "Line"`},
+			{`output: [{field: MemberID}]`, `output column for field MemberID has no name`},
+			{`output: [{name: A, field: MemberID}, {name: a, field: FobNumber}]`, `output column a is declared twice`},
+			{`output: [{name: A, field: MemberID}, {name: B, field: MemberID}]`,
+				`output field MemberID is mapped by more than one column`},
+			{`input: [{name: Member, field: MemberID, required: true}]`,
+				`input field HomeClub must be mapped by a required column`},
+			{`input: {name: Member}`, `failed to parse schema`},
+		}
+		for _, tt := range tests {
+			_, err := Load(writeSchema(t, "schema.yml", tt.content))
+			require.Error(t, err, tt.content)
+			assert.Contains(t, err.Error(), tt.err)
+		}
+	})
+
+	t.Run("should reject unknown file types", func(t *testing.T) {
+		path := writeSchema(t, "schema.toml", "")
+		_, err := Load(path)
+		assert.EqualError(t, err, "unsupported schema file "+path+", expected .yaml, .yml or .json")
+	})
+}
+
+func TestColumnsValidate(t *testing.T) {
+	valid := model.ClubTransferRow{
+		MemberID:       "12345",
+		FobNumber:      "FOB001",
+		FirstName:      "John",
+		LastName:       "Doe",
+		MembershipType: "Premium",
+		HomeClub:       "CLUB A",
+		TargetClub:     "CLUB B",
+	}
+	columns := DefaultInput()
+	assert.Empty(t, columns.Validate(valid))
+
+	missing := valid
+	missing.MemberID = " "
+	missing.FobNumber = ""
+	missing.TargetClub = ""
+	assert.Equal(t, []string{"missing Member Id, Fob Number, Target Club"}, columns.Validate(missing))
+
+	assert.Equal(t, []string{
+		"missing Member Id, Fob Number, First Name, Last Name, Membership Type, Home Club, Target Club",
+	}, columns.Validate(model.ClubTransferRow{}))
+
+	// Optional columns may be left empty
+	columns[1].Required = false
+	assert.Empty(t, columns.Validate(model.ClubTransferRow{MemberID: "1", HomeClub: "A", TargetClub: "B",
+		FirstName: "John", LastName: "Doe", MembershipType: "Premium"}))
+}
+
+func TestColumnValues(t *testing.T) {
+	row := model.ClubTransferRow{}
+	Column{Field: "FobNumber"}.SetRowValue(&row, "FOB001")
+	assert.Equal(t, "FOB001", row.FobNumber)
+	assert.Equal(t, "FOB001", Column{Field: "FobNumber"}.RowValue(row))
+
+	date := time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)
+	data := model.ClubTransferData{TransferType: "TRANSFER IN", TransferDate: date}
+	assert.Equal(t, "TRANSFER IN", Column{Field: "TransferType"}.DataValue(data))
+	assert.Equal(t, date, Column{Field: "TransferDate"}.DataValue(data))
+	assert.True(t, Column{Field: "TransferDate"}.IsDate())
+	assert.False(t, Column{Field: "TransferType"}.IsDate())
+}
@@ -2,6 +2,7 @@ package secrets
 
 import (
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 )
@@ -9,7 +10,11 @@ import (
 // SecretsManagerAPI defines the interface for AWS Secrets Manager operations
 // This interface allows for mocking in tests
 type SecretsManagerAPI interface {
-	GetSecretValue(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error)
+	GetSecretValueWithContext(
+		ctx aws.Context,
+		input *secretsmanager.GetSecretValueInput,
+		opts ...request.Option,
+	) (*secretsmanager.GetSecretValueOutput, error)
 }
 
 // SessionCreator defines the interface for creating AWS sessions
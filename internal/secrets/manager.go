@@ -2,6 +2,7 @@
 package secrets
 
 import (
+	"context"
 	"fmt"
 
 	"coral.daniel-guo.com/internal/logger"
@@ -35,7 +36,7 @@ func NewManager(config Config) *Manager {
 }
 
 // GetSecret gets a secret from AWS Secrets Manager
-func (m *Manager) GetSecret(secretName string) (string, error) {
+func (m *Manager) GetSecret(ctx context.Context, secretName string) (string, error) {
 	logger.Info("Getting secret: %s", secretName)
 
 	// Create a new AWS session with the configuration
@@ -55,7 +56,7 @@ func (m *Manager) GetSecret(secretName string) (string, error) {
 	}
 
 	// Get the secret value
-	result, err := svc.GetSecretValue(input)
+	result, err := svc.GetSecretValueWithContext(ctx, input)
 	if err != nil {
 		return "", fmt.Errorf("failed to get secret: %w", err)
 	}
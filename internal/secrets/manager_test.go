@@ -1,10 +1,12 @@
 package secrets
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 )
@@ -152,8 +154,10 @@ type mockSecretsManagerClient struct {
 	GetSecretValueFunc func(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error)
 }
 
-func (m *mockSecretsManagerClient) GetSecretValue(
+func (m *mockSecretsManagerClient) GetSecretValueWithContext(
+	_ aws.Context,
 	input *secretsmanager.GetSecretValueInput,
+	_ ...request.Option,
 ) (*secretsmanager.GetSecretValueOutput, error) {
 	return m.GetSecretValueFunc(input)
 }
@@ -306,7 +310,7 @@ func TestTestableManager_GetSecret(t *testing.T) {
 			config := Config{Region: "us-east-1"}
 			manager := NewTestableManager(config, nil, clientFactory)
 
-			result, err := manager.GetSecret(tt.secretName)
+			result, err := manager.GetSecret(context.Background(), tt.secretName)
 
 			if tt.expectError {
 				if err == nil {
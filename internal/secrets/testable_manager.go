@@ -1,6 +1,7 @@
 package secrets
 
 import (
+	"context"
 	"fmt"
 
 	"coral.daniel-guo.com/internal/logger"
@@ -44,7 +45,7 @@ func NewTestableManagerWithDefaults(config Config) *TestableManager {
 }
 
 // GetSecret gets a secret from AWS Secrets Manager using the injected dependencies
-func (m *TestableManager) GetSecret(secretName string) (string, error) {
+func (m *TestableManager) GetSecret(ctx context.Context, secretName string) (string, error) {
 	logger.Info("Getting secret: %s", secretName)
 
 	// Create AWS config
@@ -61,7 +62,7 @@ func (m *TestableManager) GetSecret(secretName string) (string, error) {
 	}
 
 	// Get the secret value
-	result, err := client.GetSecretValue(input)
+	result, err := client.GetSecretValueWithContext(ctx, input)
 	if err != nil {
 		return "", fmt.Errorf("failed to get secret: %w", err)
 	}
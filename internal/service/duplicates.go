@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"coral.daniel-guo.com/internal/config"
+	"coral.daniel-guo.com/internal/history"
+	"coral.daniel-guo.com/internal/logger"
+	"coral.daniel-guo.com/internal/model"
+)
+
+// duplicateChecker finds rows repeated within the input file or notified by a previous run
+type duplicateChecker struct {
+	history      *history.History
+	transferType string
+	period       string
+
+	// clubKey returns the name a club is recorded by
+	clubKey func(club string) string
+
+	// seen maps every transfer read so far to its first line
+	seen map[history.Key]int
+}
+
+// newDuplicateChecker creates a checker for the request, loading the
+// transfer history when one is configured
+func (s *Service) newDuplicateChecker(req TransferRequest) (*duplicateChecker, error) {
+	checker := &duplicateChecker{
+		transferType: strings.ToUpper(req.TransferType),
+		period:       req.Period.Code(),
+		clubKey:      s.clubKey,
+		seen:         make(map[history.Key]int),
+	}
+	if s.config.HistoryPath == "" {
+		return checker, nil
+	}
+
+	transferHistory, err := history.Open(s.config.HistoryPath)
+	if err != nil {
+		return nil, err
+	}
+	checker.history = transferHistory
+	return checker, nil
+}
+
+// check returns why the row is a duplicate, or an empty string if it is not,
+// and the clubs of the row not yet notified of the transfer. A row repeating
+// an earlier row of the input file leaves no club to notify.
+func (c *duplicateChecker) check(row model.ClubTransferRow) (reason string, pending []string) {
+	key := history.NewKey(row.MemberID, c.clubKey(row.HomeClub), c.clubKey(row.TargetClub))
+	if line, ok := c.seen[key]; ok {
+		return fmt.Sprintf("duplicate of line %d: member %s from %s to %s",
+			line, key.MemberID, key.HomeClub, key.TargetClub), nil
+	}
+	c.seen[key] = row.Line
+
+	if c.history == nil {
+		return "", nil
+	}
+	var notified []history.Entry
+	var notifiedClubs []string
+	for _, club := range []string{row.HomeClub, row.TargetClub} {
+		if previous, ok := c.history.Previous(key, c.clubKey(club), c.transferType, c.period); ok {
+			notified = append(notified, previous)
+			notifiedClubs = append(notifiedClubs, c.clubKey(club))
+		} else {
+			pending = append(pending, club)
+		}
+	}
+
+	switch len(notified) {
+	case 0:
+		return "", nil
+	case 1:
+		return fmt.Sprintf("member %s from %s to %s already notified to %s in %s %s on %s",
+			key.MemberID, key.HomeClub, key.TargetClub, notifiedClubs[0], notified[0].TransferType,
+			notified[0].Period, notified[0].NotifiedAt.Format("2006-01-02")), pending
+	}
+	previous := notified[0]
+	if notified[1].NotifiedAt.After(previous.NotifiedAt) {
+		previous = notified[1]
+	}
+	return fmt.Sprintf("member %s from %s to %s already notified in %s %s on %s",
+		key.MemberID, key.HomeClub, key.TargetClub, previous.TransferType, previous.Period,
+		previous.NotifiedAt.Format("2006-01-02")), nil
+}
+
+// onDuplicate returns the configured duplicate action, warn by default
+func (s *Service) onDuplicate() string {
+	if s.config.OnDuplicate == "" {
+		return config.OnDuplicateWarn
+	}
+	return strings.ToLower(s.config.OnDuplicate)
+}
+
+// validateOnDuplicate checks the configured duplicate action is supported
+func (s *Service) validateOnDuplicate() []Problem {
+	switch s.onDuplicate() {
+	case config.OnDuplicateSkip, config.OnDuplicateWarn, config.OnDuplicateFail:
+		return nil
+	}
+	return []Problem{{
+		Message: fmt.Sprintf("unsupported duplicate action %q, expected skip, warn or fail", s.config.OnDuplicate),
+	}}
+}
+
+// recordHistory adds the transfers a club was notified of to the transfer
+// history, so a later run only leaves them out for that club. Dry and test
+// runs notify no club and are not recorded.
+func (s *Service) recordHistory(req TransferRequest, club string, data []model.ClubTransferData) {
+	if s.history == nil || s.config.DryRun || s.config.TestEmail != "" {
+		return
+	}
+
+	entries := make([]history.Entry, 0, len(data))
+	for _, transfer := range data {
+		entries = append(entries, history.Entry{
+			MemberID:     transfer.MemberID,
+			HomeClub:     s.clubKey(transfer.HomeClub),
+			TargetClub:   s.clubKey(transfer.TargetClub),
+			Club:         club,
+			TransferType: strings.ToUpper(req.TransferType),
+			Period:       req.Period.Code(),
+		})
+	}
+	if err := s.history.Record(entries...); err != nil {
+		logger.Error("Failed to record transfer history: %v", err)
+	}
+}
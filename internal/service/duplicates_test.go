@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"coral.daniel-guo.com/internal/config"
+	"coral.daniel-guo.com/internal/history"
+	"coral.daniel-guo.com/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const duplicatesCSV = `Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club
+12345,FOB001,John,Doe,Premium,CLUB A,CLUB B
+67890,FOB002,Jane,Smith,Standard,CLUB B,CLUB A
+12345,FOB001,John,Doe,Premium,club a,CLUB B
+12345,FOB001,John,Doe,Premium,CLUB A,CLUB C`
+
+func (suite *TransferServiceTestSuite) prepareDuplicates(onDuplicate string) *preparedRun {
+	req := suite.request("PIF")
+	req.FileName = suite.createTestCSVFile("duplicates.csv", duplicatesCSV)
+	suite.mockLocationRepo.On("FindByNames", []string{"CLUB A", "CLUB B", "CLUB C"}).
+		Return(map[string]*model.Location{
+			"CLUB A": {ID: "1", Name: "CLUB A", Email: "a@example.com"},
+			"CLUB B": {ID: "2", Name: "CLUB B", Email: "b@example.com"},
+			"CLUB C": {ID: "3", Name: "CLUB C", Email: "c@example.com"},
+		}, nil)
+
+	suite.service.config.OnDuplicate = onDuplicate
+	run, err := suite.service.prepare(context.Background(), req, suite.mockLocationRepo)
+	require.NoError(suite.T(), err)
+	suite.T().Cleanup(func() {
+		_ = run.transfers.Close()
+	})
+	return run
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareDuplicatesWarn() {
+	run := suite.prepareDuplicates(config.OnDuplicateWarn)
+
+	assert.Empty(suite.T(), run.problems)
+	assert.Empty(suite.T(), run.rejects)
+	assert.Equal(suite.T(), 3, run.transfers.Count("CLUB B"))
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareDuplicatesSkip() {
+	run := suite.prepareDuplicates(config.OnDuplicateSkip)
+
+	assert.Empty(suite.T(), run.problems)
+	require.Len(suite.T(), run.rejects, 1)
+	assert.Equal(suite.T(), 4, run.rejects[0].Row.Line)
+	assert.Equal(suite.T(), "duplicate of line 2: member 12345 from CLUB A to CLUB B", run.rejects[0].Reason)
+	assert.Equal(suite.T(), 2, run.transfers.Count("CLUB B"))
+	// A member moving to a different club is not a duplicate
+	assert.Equal(suite.T(), 1, run.transfers.Count("CLUB C"))
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareDuplicatesFail() {
+	run := suite.prepareDuplicates(config.OnDuplicateFail)
+
+	assert.Equal(suite.T(), []Problem{
+		{Line: 4, Message: "duplicate of line 2: member 12345 from CLUB A to CLUB B"},
+	}, run.problems)
+	assert.Empty(suite.T(), run.rejects)
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareDuplicatesInvalidAction() {
+	run := suite.prepareDuplicates("ignore")
+
+	assert.Equal(suite.T(), []Problem{
+		{Message: `unsupported duplicate action "ignore", expected skip, warn or fail`},
+	}, run.problems)
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareDuplicatesAgainstHistory() {
+	historyPath := filepath.Join(suite.tempDir, "history.jsonl")
+	previousRun, err := history.Open(historyPath)
+	require.NoError(suite.T(), err)
+	req := suite.request("PIF")
+	require.NoError(suite.T(), previousRun.Record(
+		history.Entry{
+			MemberID:     "67890",
+			HomeClub:     "CLUB B",
+			TargetClub:   "CLUB A",
+			TransferType: "PIF",
+			Period:       "2024-12",
+			NotifiedAt:   time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		// Transfers notified by the same run are found again when it is resumed
+		history.Entry{MemberID: "12345", HomeClub: "CLUB A", TargetClub: "CLUB C", TransferType: "PIF",
+			Period: req.Period.Code()},
+	))
+
+	suite.service.config.HistoryPath = historyPath
+	run := suite.prepareDuplicates(config.OnDuplicateFail)
+
+	assert.Equal(suite.T(), []Problem{
+		{Line: 3, Message: "member 67890 from CLUB B to CLUB A already notified in PIF 2024-12 on 2025-01-02"},
+		{Line: 4, Message: "duplicate of line 2: member 12345 from CLUB A to CLUB B"},
+	}, run.problems)
+	assert.NotNil(suite.T(), run.history)
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareDuplicatesNotifiedToOneClub() {
+	historyPath := filepath.Join(suite.tempDir, "history.jsonl")
+	previousRun, err := history.Open(historyPath)
+	require.NoError(suite.T(), err)
+	// The home club was notified of the transfer but the target club's email failed
+	require.NoError(suite.T(), previousRun.Record(history.Entry{
+		MemberID:     "67890",
+		HomeClub:     "CLUB B",
+		TargetClub:   "CLUB A",
+		Club:         "CLUB B",
+		TransferType: "PIF",
+		Period:       "2024-12",
+		NotifiedAt:   time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+	}))
+
+	suite.service.config.HistoryPath = historyPath
+	run := suite.prepareDuplicates(config.OnDuplicateSkip)
+
+	// Only the within-file duplicate is rejected
+	require.Len(suite.T(), run.rejects, 1)
+	assert.Equal(suite.T(), 4, run.rejects[0].Row.Line)
+
+	// The target club still hears about the member
+	transfersIn := 0
+	for _, transfer := range suite.clubTransfers(run.transfers, "CLUB A") {
+		if transfer.MemberID == "67890" {
+			assert.Equal(suite.T(), "TRANSFER IN", transfer.TransferType)
+			transfersIn++
+		}
+	}
+	assert.Equal(suite.T(), 1, transfersIn)
+	for _, transfer := range suite.clubTransfers(run.transfers, "CLUB B") {
+		assert.NotEqual(suite.T(), "67890", transfer.MemberID)
+	}
+
+	run = suite.prepareDuplicates(config.OnDuplicateFail)
+	assert.Contains(suite.T(), run.problems, Problem{
+		Line:    3,
+		Message: "member 67890 from CLUB B to CLUB A already notified to CLUB B in PIF 2024-12 on 2025-01-02",
+	})
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareDuplicatesIgnorePunctuation() {
+	historyPath := filepath.Join(suite.tempDir, "history.jsonl")
+	previousRun, err := history.Open(historyPath)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), previousRun.Record(history.Entry{
+		MemberID: "67890", HomeClub: "CLUB-B", TargetClub: "Club A.", TransferType: "PIF", Period: "2024-12",
+	}))
+
+	suite.service.config.HistoryPath = historyPath
+	run := suite.prepareDuplicates(config.OnDuplicateFail)
+
+	require.NotEmpty(suite.T(), run.problems)
+	assert.Equal(suite.T(), 3, run.problems[0].Line)
+	assert.Contains(suite.T(), run.problems[0].Message, "member 67890 from CLUB B to CLUB A already notified")
+}
+
+func (suite *TransferServiceTestSuite) TestRecordHistory() {
+	historyPath := filepath.Join(suite.tempDir, "history.jsonl")
+	transferHistory, err := history.Open(historyPath)
+	require.NoError(suite.T(), err)
+	req := suite.request("DD")
+	data := []model.ClubTransferData{
+		{MemberID: "12345", HomeClub: "CLUB A", TargetClub: "CLUB B", TransferType: "TRANSFER OUT"},
+	}
+	key := history.NewKey("12345", "CLUB A", "CLUB B")
+
+	// Without a history nothing is recorded
+	suite.service.recordHistory(req, "CLUB A", data)
+
+	suite.service.history = transferHistory
+	suite.service.config.TestEmail = "tester@example.com"
+	suite.service.recordHistory(req, "CLUB A", data)
+	_, found := transferHistory.Previous(key, "CLUB A", "PIF", "")
+	assert.False(suite.T(), found)
+
+	suite.service.config.TestEmail = ""
+	suite.service.recordHistory(req, "CLUB A", data)
+
+	reopened, err := history.Open(historyPath)
+	require.NoError(suite.T(), err)
+	previous, found := reopened.Previous(key, "CLUB A", "PIF", "")
+	require.True(suite.T(), found)
+	assert.Equal(suite.T(), "DD", previous.TransferType)
+	assert.Equal(suite.T(), req.Period.Code(), previous.Period)
+
+	// The target club has not been notified
+	_, found = reopened.Previous(key, "CLUB B", "PIF", "")
+	assert.False(suite.T(), found)
+}
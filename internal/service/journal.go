@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"coral.daniel-guo.com/internal/journal"
+	"coral.daniel-guo.com/internal/logger"
+)
+
+// openJournal opens the delivery journal for the run, or returns nil when
+// journaling is disabled. Dry and test runs notify no club and are not
+// journaled, so a later real run of the same file does not skip any club.
+func (s *Service) openJournal(req TransferRequest) (*journal.Journal, error) {
+	if s.config.DryRun || s.config.TestEmail != "" || s.config.JournalDir == "" {
+		if req.Resume {
+			return nil, fmt.Errorf("resume requires a journal directory and cannot be used with dry run or test email")
+		}
+		return nil, nil
+	}
+
+	fileHash, err := journal.HashFile(req.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash input file: %w", err)
+	}
+	if req.Sheet != "" {
+		// Each sheet of a workbook is a separate run
+		fileHash += ":" + req.Sheet
+	}
+
+	runJournal, err := journal.Open(s.config.JournalDir, journal.NewKey(fileHash, req.TransferType, req.Period))
+	if err != nil {
+		return nil, err
+	}
+	if req.Resume && !runJournal.Existed() {
+		// Resuming without the original journal would resend to every club
+		return nil, fmt.Errorf("no journal %s to resume, use the period and journal directory of the original run",
+			runJournal.Path())
+	}
+
+	logger.Info("Recording club deliveries in journal %s", runJournal.Path())
+	return runJournal, nil
+}
+
+// skipDelivered removes clubs the journal already records as delivered
+func (s *Service) skipDelivered(clubs []string, runJournal *journal.Journal) []string {
+	pending := make([]string, 0, len(clubs))
+	for _, club := range clubs {
+		if runJournal.Delivered(club) {
+			logger.Info("Skipping club %s: already delivered in a previous run", club)
+			continue
+		}
+		pending = append(pending, club)
+	}
+
+	logger.Info("Resuming run: %d of %d clubs left to deliver", len(pending), len(clubs))
+	return pending
+}
+
+// recordResult stores the outcome of a club in the journal
+func (s *Service) recordResult(runJournal *journal.Journal, res ClubResult) {
+	if runJournal == nil {
+		return
+	}
+
+	entry := journal.Entry{
+		Club:      res.ClubName,
+		Status:    journal.StatusSent,
+		Recipient: res.SentTo,
+		MessageID: res.MessageID,
+	}
+	switch {
+	case errors.Is(res.Err, ErrRunCancelled):
+		entry.Status = journal.StatusUnsent
+	case res.Err != nil:
+		entry.Status = journal.StatusFailed
+		entry.Error = res.Err.Error()
+	}
+
+	if err := runJournal.Record(entry); err != nil {
+		logger.Error("Failed to record club %s in journal: %v", res.ClubName, err)
+	}
+}
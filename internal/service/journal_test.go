@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+
+	"coral.daniel-guo.com/internal/config"
+	"coral.daniel-guo.com/internal/journal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *TransferServiceTestSuite) journalService() *Service {
+	return NewService(&config.AppConfig{JournalDir: filepath.Join(suite.tempDir, "journal")})
+}
+
+func (suite *TransferServiceTestSuite) TestOpenJournalDisabled() {
+	service := NewService(&config.AppConfig{DryRun: true, JournalDir: suite.tempDir})
+
+	runJournal, err := service.openJournal(suite.request("PIF"))
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), runJournal)
+
+	req := suite.request("PIF")
+	req.Resume = true
+	_, err = service.openJournal(req)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *TransferServiceTestSuite) TestTestEmailRunIsNotJournaled() {
+	req := suite.request("PIF")
+	req.FileName = suite.createTestCSVFile("input.csv", "Member Id\n1")
+	journalDir := filepath.Join(suite.tempDir, "journal")
+
+	testRun := NewService(&config.AppConfig{JournalDir: journalDir, TestEmail: "tester@example.com"})
+	testJournal, err := testRun.openJournal(req)
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), testJournal)
+	testRun.recordResult(testJournal, ClubResult{ClubName: "CLUB A", SentTo: "tester@example.com"})
+
+	resumed := req
+	resumed.Resume = true
+	_, err = testRun.openJournal(resumed)
+	assert.Error(suite.T(), err)
+
+	// A real run of the same file still delivers every club
+	runJournal, err := suite.journalService().openJournal(req)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), runJournal.Delivered("CLUB A"))
+}
+
+func (suite *TransferServiceTestSuite) TestResumeSkipsDeliveredClubs() {
+	service := suite.journalService()
+	req := suite.request("PIF")
+	req.FileName = suite.createTestCSVFile("input.csv", "Member Id\n1")
+
+	runJournal, err := service.openJournal(req)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), runJournal)
+
+	service.recordResult(runJournal, ClubResult{ClubName: "CLUB A", SentTo: "a@example.com", MessageID: "id-a"})
+	service.recordResult(runJournal, ClubResult{ClubName: "CLUB B", Err: errors.New("throttled")})
+	service.recordResult(runJournal, ClubResult{ClubName: "CLUB C", Err: ErrRunCancelled})
+
+	// A second run of the same file picks up the same journal
+	reopened, err := service.openJournal(req)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), runJournal.Path(), reopened.Path())
+
+	pending := service.skipDelivered([]string{"CLUB A", "CLUB B", "CLUB C"}, reopened)
+
+	assert.Equal(suite.T(), []string{"CLUB B", "CLUB C"}, pending)
+
+	entries := reopened.Entries()
+	require.Len(suite.T(), entries, 3)
+	assert.Equal(suite.T(), journal.StatusUnsent, entries[2].Status)
+	assert.Zero(suite.T(), entries[2].Attempts)
+}
+
+func (suite *TransferServiceTestSuite) TestResumeRequiresExistingJournal() {
+	service := suite.journalService()
+	req := suite.request("PIF")
+	req.FileName = suite.createTestCSVFile("input.csv", "Member Id\n1")
+	req.Resume = true
+
+	_, err := service.openJournal(req)
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "no journal")
+
+	// Once a run has recorded its journal it can be resumed
+	req.Resume = false
+	runJournal, err := service.openJournal(req)
+	require.NoError(suite.T(), err)
+	service.recordResult(runJournal, ClubResult{ClubName: "CLUB A", Err: errors.New("throttled")})
+
+	req.Resume = true
+	resumed, err := service.openJournal(req)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), resumed.Existed())
+}
+
+func (suite *TransferServiceTestSuite) TestOpenJournalChangesWithInput() {
+	service := suite.journalService()
+
+	first := suite.request("PIF")
+	first.FileName = suite.createTestCSVFile("first.csv", "Member Id\n1")
+	second := suite.request("PIF")
+	second.FileName = suite.createTestCSVFile("second.csv", "Member Id\n2")
+
+	firstJournal, err := service.openJournal(first)
+	require.NoError(suite.T(), err)
+	secondJournal, err := service.openJournal(second)
+	require.NoError(suite.T(), err)
+
+	assert.NotEqual(suite.T(), firstJournal.Path(), secondJournal.Path())
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"coral.daniel-guo.com/internal/logger"
+)
+
+// Club statuses written to the run report
+const (
+	reportStatusSent     = "sent"
+	reportStatusFailed   = "failed"
+	reportStatusRendered = "rendered"
+	reportStatusUnsent   = "unsent"
+)
+
+// Report is the machine-readable summary of a run
+type Report struct {
+	TransferType string       `json:"transfer_type"`
+	InputFile    string       `json:"input_file"`
+	Period       string       `json:"period"`
+	AsOf         string       `json:"as_of"`
+	DryRun       bool         `json:"dry_run"`
+	GeneratedAt  time.Time    `json:"generated_at"`
+	Totals       ReportTotals `json:"totals"`
+	Clubs        []ClubReport `json:"clubs"`
+}
+
+// ReportTotals counts clubs by outcome
+type ReportTotals struct {
+	Clubs  int `json:"clubs"`
+	Sent   int `json:"sent"`
+	Failed int `json:"failed"`
+	Unsent int `json:"unsent"`
+}
+
+// ClubReport is the outcome of a single club
+type ClubReport struct {
+	Club             string `json:"club"`
+	LocationID       string `json:"location_id"`
+	Recipient        string `json:"recipient"`
+	SentTo           string `json:"sent_to"`
+	AttachmentName   string `json:"attachment_name"`
+	RowCount         int    `json:"row_count"`
+	TransferInCount  int    `json:"transfer_in_count"`
+	TransferOutCount int    `json:"transfer_out_count"`
+	MessageID        string `json:"message_id"`
+	Status           string `json:"status"`
+	Error            string `json:"error,omitempty"`
+}
+
+// reportHeaders are the CSV report columns, in the order written by ClubReport.record
+var reportHeaders = []string{
+	"Club",
+	"Location Id",
+	"Recipient",
+	"Sent To",
+	"Attachment Name",
+	"Row Count",
+	"Transfer In Count",
+	"Transfer Out Count",
+	"Message Id",
+	"Status",
+	"Error",
+}
+
+// record returns the club report as a CSV record
+func (c ClubReport) record() []string {
+	return []string{
+		c.Club,
+		c.LocationID,
+		c.Recipient,
+		c.SentTo,
+		c.AttachmentName,
+		strconv.Itoa(c.RowCount),
+		strconv.Itoa(c.TransferInCount),
+		strconv.Itoa(c.TransferOutCount),
+		c.MessageID,
+		c.Status,
+		c.Error,
+	}
+}
+
+// newReport builds the run report from the club results
+func (s *Service) newReport(req TransferRequest, results []ClubResult) Report {
+	report := Report{
+		TransferType: req.TransferType,
+		InputFile:    req.FileName,
+		Period:       req.Period.Code(),
+		AsOf:         req.AsOf.Format("2006-01-02"),
+		DryRun:       s.config.DryRun,
+		GeneratedAt:  time.Now().UTC(),
+		Clubs:        make([]ClubReport, 0, len(results)),
+	}
+
+	for _, res := range results {
+		club := ClubReport{
+			Club:             res.ClubName,
+			LocationID:       res.LocationID,
+			Recipient:        res.Recipient,
+			SentTo:           res.SentTo,
+			AttachmentName:   res.AttachmentName,
+			RowCount:         res.RowCount,
+			TransferInCount:  res.TransferInCount,
+			TransferOutCount: res.TransferOutCount,
+			MessageID:        res.MessageID,
+			Status:           reportStatusSent,
+		}
+
+		switch {
+		case errors.Is(res.Err, ErrRunCancelled):
+			club.Status = reportStatusUnsent
+			report.Totals.Unsent++
+		case res.Err != nil:
+			club.Status = reportStatusFailed
+			club.Error = res.Err.Error()
+			report.Totals.Failed++
+		case s.config.DryRun:
+			club.Status = reportStatusRendered
+		default:
+			report.Totals.Sent++
+		}
+
+		report.Clubs = append(report.Clubs, club)
+	}
+	report.Totals.Clubs = len(report.Clubs)
+
+	return report
+}
+
+// writeReport writes the run report to path, as CSV when the path ends in .csv and JSON otherwise
+func (s *Service) writeReport(path string, req TransferRequest, results []ClubResult) error {
+	report := s.newReport(req, results)
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create report directory: %w", err)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil {
+			logger.Warn("Failed to close report file: %v", cerr)
+		}
+	}()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		writer := csv.NewWriter(file)
+		if err := writer.Write(reportHeaders); err != nil {
+			return fmt.Errorf("failed to write report headers: %w", err)
+		}
+		for _, club := range report.Clubs {
+			if err := writer.Write(club.record()); err != nil {
+				return fmt.Errorf("failed to write report record: %w", err)
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	} else {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+
+	logger.Info("Run report written to %s", path)
+	return nil
+}
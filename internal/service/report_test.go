@@ -0,0 +1,121 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"coral.daniel-guo.com/internal/config"
+	"coral.daniel-guo.com/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func reportResults() []ClubResult {
+	return []ClubResult{
+		{
+			ClubName:         "CLUB A",
+			LocationID:       "loc-1",
+			Recipient:        "cluba@example.com",
+			SentTo:           "cluba@example.com",
+			AttachmentName:   "pif_club_transfer_CLUB A.csv",
+			RowCount:         3,
+			TransferInCount:  2,
+			TransferOutCount: 1,
+			MessageID:        "ses-1",
+		},
+		{
+			ClubName: "CLUB B",
+			RowCount: 1,
+			Err:      errors.New("club CLUB B: location not found"),
+		},
+		{
+			ClubName: "CLUB C",
+			RowCount: 2,
+			Err:      fmt.Errorf("club CLUB C: %w", ErrRunCancelled),
+		},
+	}
+}
+
+func reportRequest() TransferRequest {
+	return TransferRequest{
+		TransferType: "PIF",
+		FileName:     "input.csv",
+		Period:       model.NewMonthPeriod(2025, time.May),
+		AsOf:         time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (suite *TransferServiceTestSuite) TestWriteReportJSON() {
+	path := filepath.Join(suite.tempDir, "reports", "run.json")
+
+	err := suite.service.writeReport(path, reportRequest(), reportResults())
+	require.NoError(suite.T(), err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(suite.T(), err)
+
+	var report Report
+	require.NoError(suite.T(), json.Unmarshal(content, &report))
+
+	assert.Equal(suite.T(), "PIF", report.TransferType)
+	assert.Equal(suite.T(), "2025-05", report.Period)
+	assert.Equal(suite.T(), "2025-06-02", report.AsOf)
+	assert.Equal(suite.T(), ReportTotals{Clubs: 3, Sent: 1, Failed: 1, Unsent: 1}, report.Totals)
+	require.Len(suite.T(), report.Clubs, 3)
+
+	assert.Equal(suite.T(), ClubReport{
+		Club:             "CLUB A",
+		LocationID:       "loc-1",
+		Recipient:        "cluba@example.com",
+		SentTo:           "cluba@example.com",
+		AttachmentName:   "pif_club_transfer_CLUB A.csv",
+		RowCount:         3,
+		TransferInCount:  2,
+		TransferOutCount: 1,
+		MessageID:        "ses-1",
+		Status:           "sent",
+	}, report.Clubs[0])
+	assert.Equal(suite.T(), "failed", report.Clubs[1].Status)
+	assert.Equal(suite.T(), "club CLUB B: location not found", report.Clubs[1].Error)
+	assert.Equal(suite.T(), "unsent", report.Clubs[2].Status)
+	assert.Empty(suite.T(), report.Clubs[2].Error)
+}
+
+func (suite *TransferServiceTestSuite) TestWriteReportCSV() {
+	path := filepath.Join(suite.tempDir, "run.CSV")
+
+	err := suite.service.writeReport(path, reportRequest(), reportResults())
+	require.NoError(suite.T(), err)
+
+	file, err := os.Open(path)
+	require.NoError(suite.T(), err)
+	defer func() {
+		_ = file.Close()
+	}()
+	records, err := csv.NewReader(file).ReadAll()
+	require.NoError(suite.T(), err)
+
+	require.Len(suite.T(), records, 4)
+	assert.Equal(suite.T(), reportHeaders, records[0])
+	assert.Equal(suite.T(), []string{
+		"CLUB A", "loc-1", "cluba@example.com", "cluba@example.com", "pif_club_transfer_CLUB A.csv",
+		"3", "2", "1", "ses-1", "sent", "",
+	}, records[1])
+	assert.Equal(suite.T(), "failed", records[2][9])
+	assert.Equal(suite.T(), "unsent", records[3][9])
+}
+
+func (suite *TransferServiceTestSuite) TestNewReportDryRun() {
+	service := NewService(&config.AppConfig{DryRun: true})
+
+	report := service.newReport(reportRequest(), reportResults())
+
+	assert.True(suite.T(), report.DryRun)
+	assert.Equal(suite.T(), "rendered", report.Clubs[0].Status)
+	assert.Equal(suite.T(), ReportTotals{Clubs: 3, Sent: 0, Failed: 1, Unsent: 1}, report.Totals)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"coral.daniel-guo.com/internal/locations"
+	"coral.daniel-guo.com/internal/logger"
+	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/repository"
+)
+
+// maxSuggestions is the number of locations suggested for a club without one
+const maxSuggestions = 3
+
+// suggestLocations returns the locations with the names closest to each club
+// without a resolved location, logging them. Suggestions are best effort: when
+// the locations cannot be listed none are made.
+func (s *Service) suggestLocations(
+	ctx context.Context,
+	clubs []string,
+	resolved map[string]*model.Location,
+	locationRepo repository.LocationRepositoryInterface,
+) map[string][]*model.Location {
+	var unknown []string
+	for _, club := range clubs {
+		if _, ok := resolved[club]; !ok {
+			unknown = append(unknown, club)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	var all []*model.Location
+	err := s.withRetry(ctx, "location listing", repository.IsRetryable, func(ctx context.Context) error {
+		var err error
+		all, err = locationRepo.FindAll(ctx)
+		return err
+	})
+	if err != nil {
+		logger.Warn("Failed to list locations for suggestions: %v", err)
+		return nil
+	}
+
+	byName := make(map[string]*model.Location, len(all))
+	names := make([]string, 0, len(all))
+	for _, location := range all {
+		if _, ok := byName[location.Name]; !ok {
+			byName[location.Name] = location
+			names = append(names, location.Name)
+		}
+	}
+
+	suggestions := make(map[string][]*model.Location)
+	for _, club := range unknown {
+		closest := locations.Suggest(club, names, maxSuggestions)
+		if len(closest) == 0 {
+			logger.Warn("Location not found for club %s", club)
+			continue
+		}
+		logger.Warn("Location not found for club %s, closest: %s", club, strings.Join(closest, ", "))
+		for _, name := range closest {
+			suggestions[club] = append(suggestions[club], byName[name])
+		}
+	}
+	return suggestions
+}
+
+// confirmAliases asks the operator which of the suggested locations each club
+// without a location is. A chosen location resolves the club for this run and
+// is saved as an alias for later runs. Unanswered clubs keep their suggestions.
+func (s *Service) confirmAliases(
+	clubs []string,
+	suggestions map[string][]*model.Location,
+	resolved map[string]*model.Location,
+) error {
+	answers := bufio.NewReader(s.stdin)
+	confirmed := 0
+	for _, club := range clubs {
+		matches, ok := suggestions[club]
+		if !ok {
+			continue
+		}
+
+		location, err := s.askLocation(answers, club, matches)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if location == nil {
+			continue
+		}
+
+		if s.config.LocationAliases == nil {
+			s.config.LocationAliases = locations.Aliases{}
+		}
+		s.config.LocationAliases.Set(club, location.Name)
+		resolved[club] = location
+		delete(suggestions, club)
+		confirmed++
+	}
+	if confirmed == 0 {
+		return nil
+	}
+
+	if s.config.LocationAliasesPath == "" {
+		logger.Warn("No location aliases file set, %d confirmed aliases apply to this run only", confirmed)
+		return nil
+	}
+	if err := s.config.LocationAliases.Save(s.config.LocationAliasesPath); err != nil {
+		return err
+	}
+	logger.Info("Saved %d location aliases to %s", confirmed, s.config.LocationAliasesPath)
+	return nil
+}
+
+// askLocation prompts for one of the matches until it gets a valid choice,
+// returning nil when the club is skipped and io.EOF when input runs out
+func (s *Service) askLocation(answers *bufio.Reader, club string, matches []*model.Location) (*model.Location, error) {
+	_, _ = fmt.Fprintf(s.stdout, "Location not found for club %s. Did you mean:\n", club)
+	for i, match := range matches {
+		_, _ = fmt.Fprintf(s.stdout, "  %d) %s\n", i+1, match.Name)
+	}
+
+	for {
+		_, _ = fmt.Fprintf(s.stdout, "Choose 1-%d, or press Enter to skip: ", len(matches))
+		answer, err := answers.ReadString('\n')
+		if err == io.EOF && answer == "" {
+			return nil, err
+		}
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read answer: %w", err)
+		}
+
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			return nil, nil
+		}
+		if choice, err := strconv.Atoi(answer); err == nil && choice >= 1 && choice <= len(matches) {
+			return matches[choice-1], nil
+		}
+		_, _ = fmt.Fprintf(s.stdout, "Invalid choice %q\n", answer)
+	}
+}
+
+// locationNames returns the quoted names of the matches
+func locationNames(matches []*model.Location) string {
+	names := make([]string, len(matches))
+	for i, location := range matches {
+		names[i] = strconv.Quote(location.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"coral.daniel-guo.com/internal/locations"
+	"coral.daniel-guo.com/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// allLocations are the locations listed for suggestions
+var allLocations = []*model.Location{
+	{ID: "1", Name: "St. Kilda", Email: "stkilda@example.com"},
+	{ID: "2", Name: "Sydney CBD", Email: "cbd@example.com"},
+	{ID: "3", Name: "Bondi Junction", Email: "bondi@example.com"},
+}
+
+// prepareUnknownClubs prepares a run whose clubs have no locations of their own
+func (suite *TransferServiceTestSuite) prepareUnknownClubs() (*preparedRun, error) {
+	csvContent := `Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club
+12345,FOB001,John,Doe,Premium,St Kildaa,Sydney CDB
+22222,FOB002,Amy,Lee,Basic,St Kildaa,Club X`
+	req := suite.request("PIF")
+	req.FileName = suite.createTestCSVFile("unknown.csv", csvContent)
+	suite.mockLocationRepo.On("FindByNames", []string{"CLUB X", "ST KILDAA", "SYDNEY CDB"}).
+		Return(map[string]*model.Location{}, nil)
+
+	return suite.service.prepare(context.Background(), req, suite.mockLocationRepo)
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareSuggestsLocations() {
+	suite.mockLocationRepo.On("FindAll").Return(allLocations, nil)
+
+	run, err := suite.prepareUnknownClubs()
+
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []Problem{
+		{Club: "CLUB X", Message: "location not found"},
+		{Club: "ST KILDAA", Message: `location not found, did you mean "St. Kilda"?`},
+		{Club: "SYDNEY CDB", Message: `location not found, did you mean "Sydney CBD"?`},
+	}, run.problems)
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareWithoutSuggestions() {
+	suite.mockLocationRepo.On("FindAll").Return(nil, errors.New("database error"))
+
+	run, err := suite.prepareUnknownClubs()
+
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []Problem{
+		{Club: "CLUB X", Message: "location not found"},
+		{Club: "ST KILDAA", Message: "location not found"},
+		{Club: "SYDNEY CDB", Message: "location not found"},
+	}, run.problems)
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareConfirmsAliases() {
+	suite.mockLocationRepo.On("FindAll").Return(allLocations, nil)
+	var prompts bytes.Buffer
+	suite.service.stdin = strings.NewReader("x\n1\n\n")
+	suite.service.stdout = &prompts
+	suite.service.config.ConfirmAliases = true
+	suite.service.config.LocationAliasesPath = filepath.Join(suite.tempDir, "aliases.yaml")
+
+	run, err := suite.prepareUnknownClubs()
+
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"CLUB X", "ST KILDA", "SYDNEY CDB"}, run.transfers.Clubs())
+	assert.Equal(suite.T(), 2, run.transfers.Count("ST KILDA"))
+	assert.Equal(suite.T(), "1", run.locations["ST KILDA"].ID)
+	assert.NotContains(suite.T(), run.locations, "ST KILDAA")
+	assert.Equal(suite.T(), []Problem{
+		{Club: "CLUB X", Message: "location not found"},
+		{Club: "SYDNEY CDB", Message: `location not found, did you mean "Sydney CBD"?`},
+	}, run.problems)
+	assert.Contains(suite.T(), prompts.String(), "Location not found for club ST KILDAA. Did you mean:\n  1) St. Kilda\n")
+	assert.Contains(suite.T(), prompts.String(), `Invalid choice "x"`)
+
+	saved, err := locations.LoadAliases(suite.service.config.LocationAliasesPath)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), locations.Aliases{"ST KILDAA": "St. Kilda"}, saved)
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareConfirmAliasesWithoutInput() {
+	suite.mockLocationRepo.On("FindAll").Return(allLocations, nil)
+	suite.service.stdin = strings.NewReader("")
+	suite.service.stdout = &bytes.Buffer{}
+	suite.service.config.ConfirmAliases = true
+
+	run, err := suite.prepareUnknownClubs()
+
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), run.problems, 3)
+	assert.Nil(suite.T(), suite.service.config.LocationAliases)
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareMergesConfirmedAliases() {
+	csvContent := `Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club
+12345,FOB001,John,Doe,Premium,St. Kilda,Sydney CBD
+22222,FOB002,Amy,Lee,Basic,St Kildaa,Sydney CBD`
+	req := suite.request("PIF")
+	req.FileName = suite.createTestCSVFile("merge.csv", csvContent)
+	suite.mockLocationRepo.On("FindByNames", []string{"ST KILDA", "ST KILDAA", "SYDNEY CBD"}).
+		Return(map[string]*model.Location{
+			"ST KILDA":   allLocations[0],
+			"SYDNEY CBD": allLocations[1],
+		}, nil)
+	suite.mockLocationRepo.On("FindAll").Return(allLocations, nil)
+	suite.service.stdin = strings.NewReader("1\n")
+	suite.service.stdout = &bytes.Buffer{}
+	suite.service.config.ConfirmAliases = true
+
+	run, err := suite.service.prepare(context.Background(), req, suite.mockLocationRepo)
+
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), run.problems)
+	assert.Equal(suite.T(), []string{"ST KILDA", "SYDNEY CBD"}, run.transfers.Clubs())
+	assert.Equal(suite.T(), 2, run.transfers.Count("ST KILDA"))
+	assert.NotContains(suite.T(), run.locations, "ST KILDAA")
+	assert.Equal(suite.T(), "St. Kilda", suite.service.config.LocationAliases.Resolve("St Kildaa"))
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareMergesConfirmedAliasesIntoLocation() {
+	csvContent := `Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club
+12345,FOB001,John,Doe,Premium,CBD,St. Kilda
+22222,FOB002,Amy,Lee,Basic,Sydney CBD,St. Kilda`
+	req := suite.request("PIF")
+	req.FileName = suite.createTestCSVFile("merge.csv", csvContent)
+	suite.mockLocationRepo.On("FindByNames", []string{"CBD", "ST KILDA", "SYDNEY CBD"}).
+		Return(map[string]*model.Location{
+			"ST KILDA":   allLocations[0],
+			"SYDNEY CBD": allLocations[1],
+		}, nil)
+	suite.mockLocationRepo.On("FindAll").Return(allLocations, nil)
+	suite.service.stdin = strings.NewReader("1\n")
+	suite.service.stdout = &bytes.Buffer{}
+	suite.service.config.ConfirmAliases = true
+
+	run, err := suite.service.prepare(context.Background(), req, suite.mockLocationRepo)
+
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), run.problems)
+	assert.Equal(suite.T(), []string{"ST KILDA", "SYDNEY CBD"}, run.transfers.Clubs())
+	assert.Equal(suite.T(), 2, run.transfers.Count("SYDNEY CBD"))
+	assert.Equal(suite.T(), "2", run.locations["SYDNEY CBD"].ID)
+	assert.NotContains(suite.T(), run.locations, "CBD")
+}
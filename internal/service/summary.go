@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"coral.daniel-guo.com/internal/logger"
+)
+
+// dryRunSummaryFile is the name of the summary written alongside the dry run messages
+const dryRunSummaryFile = "summary.txt"
+
+// writeDryRunSummary prints the dry run summary table and saves it to the dry run directory
+func (s *Service) writeDryRunSummary(results []ClubResult) error {
+	if err := writeSummaryTable(os.Stdout, results); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.config.DryRunDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create dry run directory: %w", err)
+	}
+
+	path := filepath.Join(s.config.DryRunDir, dryRunSummaryFile)
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create summary file: %w", err)
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil {
+			logger.Warn("Failed to close summary file: %v", cerr)
+		}
+	}()
+
+	if err := writeSummaryTable(file, results); err != nil {
+		return err
+	}
+
+	logger.Info("Dry run complete: %d messages written to %s", len(results), s.config.DryRunDir)
+	return nil
+}
+
+// writeSummaryTable writes one line per club with the recipient and message details
+func writeSummaryTable(w io.Writer, results []ClubResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLUB\tRECIPIENT\tSENT TO\tROWS\tATTACHMENT\tMESSAGE\tSTATUS")
+	for _, res := range results {
+		status := "OK"
+		if res.Err != nil {
+			status = res.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			res.ClubName,
+			valueOrDash(res.Recipient),
+			valueOrDash(res.SentTo),
+			res.RowCount,
+			valueOrDash(res.AttachmentName),
+			valueOrDash(res.MessageID),
+			status,
+		)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
+
+// valueOrDash returns "-" for empty values so table columns stay aligned
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
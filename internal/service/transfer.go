@@ -2,17 +2,33 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"coral.daniel-guo.com/internal/config"
 	"coral.daniel-guo.com/internal/csvutil"
 	"coral.daniel-guo.com/internal/email"
+	"coral.daniel-guo.com/internal/grouping"
+	"coral.daniel-guo.com/internal/history"
+	"coral.daniel-guo.com/internal/journal"
+	"coral.daniel-guo.com/internal/locations"
 	"coral.daniel-guo.com/internal/logger"
 	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/ratelimit"
 	"coral.daniel-guo.com/internal/repository"
+	"coral.daniel-guo.com/internal/retry"
 	"coral.daniel-guo.com/internal/secrets"
+	"coral.daniel-guo.com/internal/templates"
+	"coral.daniel-guo.com/internal/xlsxutil"
 )
 
 // Service handles club transfer operations
@@ -20,14 +36,37 @@ type Service struct {
 	config         *config.AppConfig
 	secretsManager *secrets.Manager
 	emailSender    *email.Sender
+	renderer       *templates.Renderer
+	limiter        *ratelimit.Limiter
+	history        *history.History
+
+	// stdin and stdout are where alias confirmations are asked and answered
+	stdin  io.Reader
+	stdout io.Writer
 }
 
 // NewService creates a new transfer service
 func NewService(cfg *config.AppConfig) *Service {
+	emailConfig := cfg.Email
+	if cfg.DryRun {
+		// Dry runs render every message to disk instead of sending it
+		emailConfig.Transport = email.TransportFile
+		emailConfig.File = email.FileConfig{Dir: cfg.DryRunDir}
+	}
+
+	if cfg.TestEmail != "" && len(emailConfig.Bcc) > 0 {
+		// Test runs must not copy anyone but the tester
+		logger.Info("Test email set, not sending bcc copies to %s", strings.Join(emailConfig.Bcc, ", "))
+		emailConfig.Bcc = nil
+	}
+
 	return &Service{
 		config:         cfg,
 		secretsManager: secrets.NewManager(cfg.Secrets),
-		emailSender:    email.NewSender(cfg.Email),
+		emailSender:    email.NewSender(emailConfig),
+		renderer:       templates.NewRenderer(cfg.Templates),
+		stdin:          os.Stdin,
+		stdout:         os.Stdout,
 	}
 }
 
@@ -35,33 +74,97 @@ func NewService(cfg *config.AppConfig) *Service {
 type TransferRequest struct {
 	TransferType string
 	FileName     string
+
+	// Sheet selects the sheet of an XLSX input file by name or 1-based
+	// position. The first sheet is read when unset.
+	Sheet string
+
+	// Period is the reporting period named in the emails. When unset it is
+	// derived from AsOf: the previous month for PIF, the previous quarter for DD.
+	Period model.Period
+
+	// AsOf is the date the run is treated as happening on and is used as the
+	// transfer date of every row. When unset the current time is used.
+	AsOf time.Time
+
+	// Resume skips clubs recorded as delivered in the run journal
+	Resume bool
 }
 
-// Process handles the club transfer workflow
-func (s *Service) Process(req TransferRequest) error {
-	// Setup database connection pool
-	dbConfig := repository.PoolConfig{
-		Environment:    s.config.Environment,
-		SecretsManager: s.secretsManager,
+// withDefaults returns a copy of the request with the period and as-of date resolved
+func (s *Service) withDefaults(req TransferRequest) TransferRequest {
+	if req.AsOf.IsZero() {
+		req.AsOf = time.Now()
 	}
+	if req.Period.IsZero() {
+		req.Period = s.reportingPeriod(req.TransferType, req.AsOf)
+	}
+	return req
+}
 
-	db, err := repository.NewPool(dbConfig)
+// Process handles the club transfer workflow
+func (s *Service) Process(ctx context.Context, req TransferRequest) error {
+	req = s.withDefaults(req)
+
+	db, err := s.connect(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return err
 	}
 	defer db.Close()
 
-	logger.Info("Starting club transfer process for type: %s", req.TransferType)
+	logger.Info("Starting club transfer process for type: %s, period: %s, as of: %s",
+		req.TransferType, req.Period, req.AsOf.Format("2006-01-02"))
 
-	// Read club transfer data from CSV file
-	data, err := s.readClubTransferData(req.FileName)
+	// Read the input and check every club before any email is sent
+	run, err := s.prepare(ctx, req, repository.NewLocationRepository(db))
 	if err != nil {
 		return fmt.Errorf("failed to read club transfer data: %w", err)
 	}
-	logger.Info("Successfully read club transfer data from %s", req.FileName)
+	defer func() {
+		if cerr := run.transfers.Close(); cerr != nil {
+			logger.Warn("Failed to remove spilled transfer files: %v", cerr)
+		}
+	}()
+	if len(run.rejects) > 0 {
+		if rerr := s.writeRejects(run.rejects); rerr != nil {
+			logger.Error("Failed to write rejected rows: %v", rerr)
+		}
+	}
+	if len(run.problems) > 0 {
+		validationErr := &ValidationError{Problems: run.problems}
+		if !s.config.DryRun {
+			return validationErr
+		}
+		logger.Warn("Continuing dry run despite problems: %v", validationErr)
+	}
+
+	// Open the delivery journal so a failed run can be resumed later
+	runJournal, err := s.openJournal(req)
+	if err != nil {
+		return fmt.Errorf("failed to open run journal: %w", err)
+	}
+	s.history = run.history
+	clubs := run.transfers.Clubs()
+	if req.Resume {
+		clubs = s.skipDelivered(clubs, runJournal)
+	}
 
 	// Send emails to clubs
-	if err := s.sendEmailToClubs(data, db, req.TransferType); err != nil {
+	defer func() {
+		_ = s.emailSender.Close()
+	}()
+	results, err := s.sendEmailToClubs(ctx, run.transfers, clubs, run.locations, req, runJournal)
+	if s.config.DryRun {
+		if serr := s.writeDryRunSummary(results); serr != nil {
+			logger.Error("Failed to write dry run summary: %v", serr)
+		}
+	}
+	if s.config.ReportPath != "" {
+		if rerr := s.writeReport(s.config.ReportPath, req, results); rerr != nil {
+			logger.Error("Failed to write run report: %v", rerr)
+		}
+	}
+	if err != nil {
 		return fmt.Errorf("failed to send emails to clubs: %w", err)
 	}
 
@@ -69,84 +172,297 @@ func (s *Service) Process(req TransferRequest) error {
 	return nil
 }
 
-// readClubTransferData reads the club transfer data from the CSV file
-func (s *Service) readClubTransferData(fileName string) (map[string][]model.ClubTransferData, error) {
-	// Read CSV and parse data
-	clubTransferRows, err := csvutil.ReadClubTransferCSV(fileName)
+// writeRejects writes the rows skipped as invalid or duplicate to the rejects file
+func (s *Service) writeRejects(rejects []csvutil.Reject) error {
+	if s.config.RejectsPath == "" {
+		logger.Warn("Skipped %d invalid or duplicate rows, no rejects file configured", len(rejects))
+		return nil
+	}
+	if err := csvutil.WriteRejectsCSV(s.config.RejectsPath, rejects, s.config.Input); err != nil {
+		return err
+	}
+	logger.Warn("Skipped %d invalid or duplicate rows, written to %s", len(rejects), s.config.RejectsPath)
+	return nil
+}
+
+// connect sets up the database connection pool
+func (s *Service) connect(ctx context.Context) (*repository.Pool, error) {
+	dbConfig := repository.PoolConfig{
+		Environment:    s.config.Environment,
+		SecretsManager: s.secretsManager,
+	}
+
+	db, err := repository.NewPool(ctx, dbConfig)
 	if err != nil {
-		return nil, fmt.Errorf("error reading club transfer data: %w", err)
-	}
-
-	transfers := make(map[string][]model.ClubTransferData)
-	for _, row := range clubTransferRows {
-		transferIn := model.ClubTransferData{
-			MemberID:       row.MemberID,
-			FobNumber:      row.FobNumber,
-			FirstName:      row.FirstName,
-			LastName:       row.LastName,
-			MembershipType: row.MembershipType,
-			HomeClub:       row.HomeClub,
-			TargetClub:     row.TargetClub,
-			TransferType:   "TRANSFER IN",
-			TransferDate:   time.Now(),
-		}
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db, nil
+}
 
-		transferOut := transferIn
-		transferOut.TransferType = "TRANSFER OUT"
+// readClubTransferData reads the club transfer data from the input file,
+// stamping every transfer with the given transfer date. The returned store
+// must be closed.
+func (s *Service) readClubTransferData(fileName string, transferDate time.Time) (*grouping.Store, error) {
+	transfers := grouping.NewStore(s.config.Grouping)
+	for row, err := range s.clubTransferRows(fileName, "") {
+		if err == nil {
+			err = s.addTransfers(transfers, row, transferDate)
+		}
+		if err != nil {
+			_ = transfers.Close()
+			return nil, err
+		}
+	}
+	return transfers, nil
+}
 
-		// Add transfers to appropriate clubs
-		if _, exists := transfers[row.TargetClub]; !exists {
-			transfers[row.TargetClub] = []model.ClubTransferData{}
+// clubTransferRows streams the rows of the input file, which is read as an
+// XLSX workbook when it has an .xlsx extension and as CSV otherwise. The file
+// is open only while the rows are iterated.
+func (s *Service) clubTransferRows(fileName, sheet string) iter.Seq2[model.ClubTransferRow, error] {
+	return func(yield func(model.ClubTransferRow, error) bool) {
+		var reader *csvutil.ClubTransferReader
+		if strings.EqualFold(filepath.Ext(fileName), ".xlsx") {
+			xlsxReader, err := xlsxutil.OpenClubTransferXLSX(fileName, sheet, s.config.Input)
+			if err != nil {
+				yield(model.ClubTransferRow{}, fmt.Errorf("error reading club transfer data: %w", err))
+				return
+			}
+			defer func() {
+				_ = xlsxReader.Close()
+			}()
+			reader = xlsxReader.ClubTransferReader
+		} else {
+			if sheet != "" {
+				logger.Warn("Ignoring sheet %s, %s is not an XLSX file", sheet, fileName)
+			}
+			file, err := os.Open(fileName)
+			if err != nil {
+				yield(model.ClubTransferRow{}, fmt.Errorf("error reading club transfer data: failed to open file: %w", err))
+				return
+			}
+			defer func() {
+				_ = file.Close()
+			}()
+			reader, err = csvutil.NewCSVReader(file, s.config.Input)
+			if err != nil {
+				yield(model.ClubTransferRow{}, fmt.Errorf("error reading club transfer data: %w", err))
+				return
+			}
 		}
-		transfers[row.TargetClub] = append(transfers[row.TargetClub], transferIn)
 
-		if _, exists := transfers[row.HomeClub]; !exists {
-			transfers[row.HomeClub] = []model.ClubTransferData{}
+		for row, err := range reader.All() {
+			if err != nil {
+				err = fmt.Errorf("error reading club transfer data: %w", err)
+			}
+			if !yield(row, err) {
+				return
+			}
 		}
-		transfers[row.HomeClub] = append(transfers[row.HomeClub], transferOut)
 	}
+}
 
-	return transfers, nil
+// addTransfers adds a row as a TRANSFER IN for the target club and a
+// TRANSFER OUT for the home club
+func (s *Service) addTransfers(transfers *grouping.Store, row model.ClubTransferRow, transferDate time.Time) error {
+	if err := s.addTransfer(transfers, row, transferDate, row.TargetClub); err != nil {
+		return err
+	}
+	return s.addTransfer(transfers, row, transferDate, row.HomeClub)
 }
 
-// getOutputFileName generates the output file name based on payment type and club name
+// addTransfer adds the transfer of a row to one of its clubs: a transfer in
+// for the target club or a transfer out for the home club
+func (s *Service) addTransfer(
+	transfers *grouping.Store,
+	row model.ClubTransferRow,
+	transferDate time.Time,
+	club string,
+) error {
+	transferType := "TRANSFER OUT"
+	if club == row.TargetClub {
+		transferType = "TRANSFER IN"
+	}
+	return transfers.Add(s.clubKey(club), model.ClubTransferData{
+		MemberID:       row.MemberID,
+		FobNumber:      row.FobNumber,
+		FirstName:      row.FirstName,
+		LastName:       row.LastName,
+		MembershipType: row.MembershipType,
+		HomeClub:       row.HomeClub,
+		TargetClub:     row.TargetClub,
+		TransferType:   transferType,
+		TransferDate:   transferDate,
+	})
+}
+
+// getOutputFileName generates the output file name based on payment type,
+// club name and attachment format
 func (s *Service) getOutputFileName(transferType, clubName string) string {
 	if transferType == "DD" {
-		return fmt.Sprintf("dd_club_transfer_%s.csv", clubName)
+		return fmt.Sprintf("dd_club_transfer_%s.%s", clubName, s.attachmentFormat())
 	}
-	return fmt.Sprintf("pif_club_transfer_%s.csv", clubName)
+	return fmt.Sprintf("pif_club_transfer_%s.%s", clubName, s.attachmentFormat())
 }
 
-// sendEmailToClubs sends emails to clubs with their transfer data
-func (s *Service) sendEmailToClubs(
-	data map[string][]model.ClubTransferData,
-	db *repository.Pool,
-	transferType string,
-) error {
-	// Create location repository
-	locationRepo := repository.NewLocationRepository(db)
+// displayName returns the name of a club's location, or the club name when
+// the location has none
+func displayName(clubName string, location *model.Location) string {
+	if location != nil && location.Name != "" {
+		return location.Name
+	}
+	return clubName
+}
 
-	clubs := make([]string, 0, len(data))
-	for club := range data {
-		clubs = append(clubs, club)
+// attachmentFormat returns the configured attachment format, CSV by default
+func (s *Service) attachmentFormat() string {
+	if s.config.AttachmentFormat == "" {
+		return config.AttachmentFormatCSV
 	}
+	return strings.ToLower(s.config.AttachmentFormat)
+}
 
-	logger.Info("Processing %d clubs for email delivery", len(clubs))
+// generateAttachment renders a club's transfers in the configured attachment format
+func (s *Service) generateAttachment(name string, data []model.ClubTransferData) (email.Attachment, error) {
+	switch s.attachmentFormat() {
+	case config.AttachmentFormatCSV:
+		content, err := csvutil.GenerateCSVContent(data, s.config.Output)
+		return email.Attachment{Name: name, ContentType: "text/csv", Content: content}, err
+	case config.AttachmentFormatXLSX:
+		content, err := xlsxutil.GenerateXLSXContent(data, s.config.Output)
+		return email.Attachment{Name: name, ContentType: xlsxContentType, Content: content}, err
+	default:
+		return email.Attachment{}, fmt.Errorf("unsupported attachment format: %s", s.config.AttachmentFormat)
+	}
+}
 
-	maxWorkers := s.config.WorkerPoolSize
-	delayMs := s.config.WorkerDelayMs
+// xlsxContentType is the media type of Excel workbooks
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 
-	if len(clubs) < maxWorkers {
-		maxWorkers = len(clubs)
+// reportingPeriod returns the period covered by a transfer run: the previous
+// month for PIF transfers and the previous quarter for DD transfers
+func (s *Service) reportingPeriod(transferType string, now time.Time) model.Period {
+	if transferType == "PIF" {
+		return model.LastMonth(now)
 	}
+	return model.LastQuarter(now)
+}
 
-	// Create channels for work distribution and error collection
-	type result struct {
-		clubName string
-		err      error
+// ErrRunCancelled marks clubs that were not sent because the run was cancelled
+var ErrRunCancelled = errors.New("run cancelled before the email was sent")
+
+// ClubResult describes the email produced for a single club
+type ClubResult struct {
+	ClubName         string
+	LocationID       string
+	Recipient        string
+	SentTo           string
+	Subject          string
+	AttachmentName   string
+	RowCount         int
+	TransferInCount  int
+	TransferOutCount int
+	MessageID        string
+	Err              error
+}
+
+// resolveLocations looks up the location of every club with a single query.
+// Clubs without a location are absent from the result.
+func (s *Service) resolveLocations(
+	ctx context.Context,
+	clubs []string,
+	locationRepo repository.LocationRepositoryInterface,
+) (map[string]*model.Location, error) {
+	var found map[string]*model.Location
+	err := s.withRetry(ctx, "location lookup", repository.IsRetryable, func(ctx context.Context) error {
+		var err error
+		found, err = locationRepo.FindByNames(ctx, clubs)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error finding locations: %w", err)
+	}
+
+	resolved := make(map[string]*model.Location, len(clubs))
+	for _, club := range clubs {
+		if location, ok := found[locations.Normalize(club)]; ok && location != nil {
+			resolved[club] = location
+		}
+	}
+	return resolved, nil
+}
+
+// clubKey returns the name transfers of a club are grouped, looked up and
+// recorded by: the normalised name of the location the club is mapped to by
+// the location aliases, or of the club itself, so that every spelling of a
+// location gets a single email
+func (s *Service) clubKey(club string) string {
+	return locations.Normalize(s.config.LocationAliases.Resolve(club))
+}
+
+// mergeClubs moves the transfers and location of every club whose key has
+// changed, as clubs confirmed as aliases do, to its key, merging clubs that
+// now resolve to the same location, and returns the clubs
+func (s *Service) mergeClubs(run *preparedRun) ([]string, error) {
+	for _, club := range run.transfers.Clubs() {
+		key := s.clubKey(club)
+		if key == club {
+			continue
+		}
+		logger.Info("Merging transfers of club %s into %s", club, key)
+		if err := run.transfers.Merge(club, key); err != nil {
+			return nil, err
+		}
+		if location, ok := run.locations[club]; ok {
+			delete(run.locations, club)
+			run.locations[key] = location
+		}
+	}
+	return run.transfers.Clubs(), nil
+}
+
+// sendEmailToClubs sends emails to the given clubs with their transfer data,
+// loading each club's transfers only when its email is sent. Clubs without a
+// resolved location are reported as failed without being sent.
+// When ctx is cancelled no new clubs are handed out, in-flight sends are
+// allowed to finish and the remaining clubs are reported as unsent.
+func (s *Service) sendEmailToClubs(
+	ctx context.Context,
+	transfers *grouping.Store,
+	clubs []string,
+	locations map[string]*model.Location,
+	req TransferRequest,
+	runJournal *journal.Journal,
+) ([]ClubResult, error) {
+	clubs = append([]string(nil), clubs...)
+	sort.Strings(clubs)
+
+	var unknownClubs []string
+	clubResults := make([]ClubResult, 0, len(clubs))
+	for _, club := range clubs {
+		if _, ok := locations[club]; !ok {
+			unknownClubs = append(unknownClubs, club)
+			clubResults = append(clubResults, ClubResult{
+				ClubName: club,
+				RowCount: transfers.Count(club),
+				Err:      fmt.Errorf("club %s: location not found", club),
+			})
+		}
 	}
-	jobs := make(chan string, len(clubs))
-	results := make(chan result, len(clubs))
+	pending := len(clubs) - len(unknownClubs)
+
+	logger.Info("Processing %d clubs for email delivery", pending)
+
+	maxWorkers := s.config.WorkerPoolSize
+	s.limiter = s.newRateLimiter(ctx)
+
+	if pending < maxWorkers {
+		maxWorkers = pending
+	}
+
+	// Create channels for work distribution and result collection
+	jobs := make(chan string)
+	results := make(chan ClubResult, pending)
 
 	// Start worker pool
 	wg := sync.WaitGroup{}
@@ -155,17 +471,35 @@ func (s *Service) sendEmailToClubs(
 		go func() {
 			defer wg.Done()
 			for clubName := range jobs {
-				err := s.sendEmail(clubName, data, transferType, locationRepo)
-				results <- result{clubName: clubName, err: err}
-				// Sleep to avoid overwhelming email service
-				time.Sleep(time.Duration(delayMs) * time.Millisecond)
+				res, err := s.sendClubEmail(ctx, clubName, transfers, req, locations[clubName])
+				res.Err = err
+				s.recordResult(runJournal, res)
+				results <- res
 			}
 		}()
 	}
 
-	// Send jobs to workers
+	// Hand out clubs to workers until the run is cancelled
+	var unsentClubs []string
 	for _, clubName := range clubs {
-		jobs <- clubName
+		if _, ok := locations[clubName]; !ok {
+			continue
+		}
+		if ctx.Err() == nil {
+			select {
+			case jobs <- clubName:
+				continue
+			case <-ctx.Done():
+			}
+		}
+		unsentClubs = append(unsentClubs, clubName)
+		res := ClubResult{
+			ClubName: clubName,
+			RowCount: transfers.Count(clubName),
+			Err:      fmt.Errorf("club %s: %w", clubName, ErrRunCancelled),
+		}
+		s.recordResult(runJournal, res)
+		clubResults = append(clubResults, res)
 	}
 	close(jobs)
 
@@ -173,106 +507,198 @@ func (s *Service) sendEmailToClubs(
 	wg.Wait()
 	close(results)
 
-	// Collect and handle errors
-	var failedClubs []string
+	// Collect results and handle errors
+	failedClubs := unknownClubs
 	for res := range results {
-		if res.err != nil {
-			logger.Error("Failed to send email to club %s: %v", res.clubName, res.err)
-			failedClubs = append(failedClubs, res.clubName)
+		clubResults = append(clubResults, res)
+		if errors.Is(res.Err, ErrRunCancelled) {
+			unsentClubs = append(unsentClubs, res.ClubName)
+		} else if res.Err != nil {
+			logger.Error("Failed to send email to club %s: %v", res.ClubName, res.Err)
+			failedClubs = append(failedClubs, res.ClubName)
 		}
 	}
 
+	sort.Slice(clubResults, func(i, j int) bool {
+		return clubResults[i].ClubName < clubResults[j].ClubName
+	})
+
+	if len(unsentClubs) > 0 {
+		sort.Strings(unsentClubs)
+		logger.Warn("Run cancelled, %d clubs left unsent: %v", len(unsentClubs), unsentClubs)
+		return clubResults, fmt.Errorf("run cancelled with %d clubs unsent and %d failed: %v",
+			len(unsentClubs), len(failedClubs), unsentClubs)
+	}
+
 	if len(failedClubs) > 0 {
-		return fmt.Errorf("failed to send emails to %d clubs: %v", len(failedClubs), failedClubs)
+		sort.Strings(failedClubs)
+		return clubResults, fmt.Errorf("failed to send emails to %d clubs: %v", len(failedClubs), failedClubs)
 	}
 
-	return nil
+	return clubResults, nil
 }
 
-func (s *Service) sendEmail(
+// sendClubEmail loads the transfers of a club and sends its email
+func (s *Service) sendClubEmail(
+	ctx context.Context,
 	clubName string,
-	data map[string][]model.ClubTransferData,
-	transferType string,
-	locationRepo repository.LocationRepositoryInterface,
-) error {
-	// Get current month and year information for email subject/content
-	now := time.Now()
-	lastMonth := now.AddDate(0, -1, 0).Month().String()
-	currentYear := now.Year()
-
-	var subject, bodyContent string
-	if transferType == "PIF" {
-		subject = fmt.Sprintf("Club Transfer for Paid in Full Members (%s %d)", lastMonth, currentYear)
-		bodyContent = fmt.Sprintf(
-			"Please find attached the Paid in Full club transfer data for your club (%s %d).",
-			lastMonth,
-			currentYear,
-		)
-	} else {
-		lastQuarter := now.AddDate(0, -3, 0).Month().String()
-		subject = fmt.Sprintf("Club Transfer for Direct Debit Members (%s - %s %d)", lastQuarter, lastMonth, currentYear)
-		bodyContent = fmt.Sprintf("Please find attached the Direct Debit club transfer data for your club (%s - %s %d).", lastQuarter, lastMonth, currentYear)
-	}
-
-	body := fmt.Sprintf(`
-		<html>
-		<head></head>
-		<body><p>Hello team,</p>
-		<p>%s</p>
-		<p>Regards</p>
-		</html>
-  `, bodyContent)
+	transfers *grouping.Store,
+	req TransferRequest,
+	location *model.Location,
+) (ClubResult, error) {
+	data, err := transfers.Transfers(clubName)
+	if err != nil {
+		result := ClubResult{ClubName: clubName, RowCount: transfers.Count(clubName)}
+		return result, fmt.Errorf("club %s: %w", clubName, err)
+	}
+	return s.sendEmail(ctx, clubName, data, req, location)
+}
 
+func (s *Service) sendEmail(
+	ctx context.Context,
+	clubName string,
+	data []model.ClubTransferData,
+	req TransferRequest,
+	location *model.Location,
+) (ClubResult, error) {
 	logger.Debug("Processing club: %s", clubName)
 
-	location, err := locationRepo.FindByName(clubName)
-	if err != nil {
-		logger.Warn("Error finding location for club %s: %v", clubName, err)
-		return fmt.Errorf("club %s: error finding location: %w", clubName, err)
+	result := ClubResult{
+		ClubName: clubName,
+		RowCount: len(data),
 	}
 
-	if location == nil {
-		logger.Warn("Location not found for club: %s", clubName)
-		return fmt.Errorf("club %s: location not found", clubName)
+	// Emails and attachments name the club's location; the club name is the
+	// key transfers are grouped, journaled and recorded by
+	name := displayName(clubName, location)
+
+	// Render subject and body for the club's reporting period
+	templateData := templates.NewData(name, req.TransferType, req.Period, data)
+	templateData.AsOf = req.AsOf
+	result.TransferInCount = templateData.TransferInCount
+	result.TransferOutCount = templateData.TransferOutCount
+	subject, body, err := s.renderer.Render(templateData)
+	if err != nil {
+		logger.Error("Error rendering email for club %s: %v", clubName, err)
+		return result, fmt.Errorf("club %s: error rendering email: %w", clubName, err)
 	}
+	result.Subject = subject
 
 	if location.Email == "" {
 		logger.Warn("Email not found for club: %s", clubName)
-		return fmt.Errorf("club %s: email not found", clubName)
+		return result, fmt.Errorf("club %s: email not found", clubName)
 	}
 
-	recipientEmail := location.Email
-	logger.Debug("Location email for %s: %s", clubName, recipientEmail)
-
-	// Generate CSV content in memory
-	csvContent, err := csvutil.GenerateCSVContent(data[clubName])
+	recipients, err := location.Recipients()
 	if err != nil {
-		logger.Error("Error generating CSV content for club %s: %v", clubName, err)
-		return fmt.Errorf("club %s: error generating CSV content: %w", clubName, err)
+		return result, fmt.Errorf("club %s: %w", clubName, err)
+	}
+	if len(recipients.To) == 0 {
+		return result, fmt.Errorf("club %s: email not found", clubName)
 	}
 
-	// Get attachment filename
-	attachmentName := s.getOutputFileName(transferType, clubName)
+	result.LocationID = location.ID
+	result.Recipient = location.Email
+	logger.Debug("Location recipients for %s: %s", clubName, strings.Join(recipients.All(), ", "))
 
-	// Determine recipient email
+	// Generate the attachment in memory
+	attachmentName := s.getOutputFileName(req.TransferType, name)
+	attachment, err := s.generateAttachment(attachmentName, data)
+	if err != nil {
+		logger.Error("Error generating attachment for club %s: %v", clubName, err)
+		return result, fmt.Errorf("club %s: error generating attachment: %w", clubName, err)
+	}
+	result.AttachmentName = attachmentName
+
+	// Determine recipients; test runs go to the test address only
 	if s.config.TestEmail != "" {
-		logger.Info("Using test email %s instead of club email %s", s.config.TestEmail, recipientEmail)
-		recipientEmail = s.config.TestEmail
-	}
-
-	// Send email with in-memory attachment
-	if err := s.emailSender.SendWithAttachment(
-		s.config.DefaultSender,
-		recipientEmail,
-		subject,
-		body,
-		attachmentName,
-		csvContent,
-	); err != nil {
+		logger.Info("Using test email %s instead of club email %s", s.config.TestEmail, location.Email)
+		recipients = model.Recipients{To: []string{s.config.TestEmail}}
+	}
+
+	result.SentTo = strings.Join(recipients.To, ", ")
+	message := &email.Message{
+		From:        s.config.DefaultSender,
+		To:          recipients.To,
+		Cc:          recipients.Cc,
+		Bcc:         recipients.Bcc,
+		Subject:     subject,
+		HTMLBody:    body,
+		Attachments: []email.Attachment{attachment},
+	}
+
+	// Send email with in-memory attachment. Every attempt waits for the shared
+	// rate limiter; an attempt that has started is completed even if the run
+	// is cancelled meanwhile, but no retry follows.
+	var messageID string
+	err = s.withRetry(ctx, "email to club "+clubName, email.IsRetryable, func(ctx context.Context) error {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return ErrRunCancelled
+		}
+		var err error
+		messageID, err = s.emailSender.Send(context.WithoutCancel(ctx), message)
+		return err
+	})
+	if errors.Is(err, ErrRunCancelled) {
+		return result, fmt.Errorf("club %s: %w", clubName, err)
+	}
+	if err != nil {
 		logger.Error("Error sending email for club %s: %v", clubName, err)
-		return fmt.Errorf("club %s: failed to send email: %w", clubName, err)
+		return result, fmt.Errorf("club %s: failed to send email: %w", clubName, err)
 	}
+	result.MessageID = messageID
+	s.recordHistory(req, clubName, data)
 
 	logger.Info("Email sent successfully to club: %s", clubName)
-	return nil
+	return result, nil
+}
+
+// newRateLimiter creates the limiter shared by all workers of a run. Dry
+// runs, and transports without a sending quota when no rate is configured,
+// are not rate limited.
+func (s *Service) newRateLimiter(ctx context.Context) *ratelimit.Limiter {
+	if s.config.DryRun {
+		return nil
+	}
+
+	rate := s.config.MaxRate
+	if rate <= 0 {
+		if !s.emailSender.RateLimited() {
+			logger.Info("Sending without a rate limit")
+			return nil
+		}
+		quota, err := s.emailSender.MaxSendRate(ctx)
+		if err != nil {
+			logger.Warn("Could not read the sending quota, falling back to %.1f emails per second: %v",
+				config.FallbackMaxRate, err)
+		}
+		rate = quota
+		if rate <= 0 {
+			rate = config.FallbackMaxRate
+		}
+	}
+
+	limiter := ratelimit.NewLimiter(rate, s.config.RateBurst)
+	logger.Info("Sending at up to %.1f emails per second (burst %d)", limiter.Rate(), limiter.Burst())
+	return limiter
+}
+
+// withRetry runs fn with the configured retry policy, logging every
+// transient failure that is retried
+func (s *Service) withRetry(
+	ctx context.Context,
+	operation string,
+	retryable retry.Classifier,
+	fn func(ctx context.Context) error,
+) error {
+	policy := s.config.Retry
+	attempt := 0
+	return retry.Do(ctx, policy, retryable, func(ctx context.Context) error {
+		attempt++
+		err := fn(ctx)
+		if err != nil && attempt < policy.MaxAttempts && retryable(err) {
+			logger.Warn("%s failed (attempt %d/%d), retrying: %v", operation, attempt, policy.MaxAttempts, err)
+		}
+		return err
+	})
 }
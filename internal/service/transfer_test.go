@@ -1,17 +1,28 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"coral.daniel-guo.com/internal/config"
+	"coral.daniel-guo.com/internal/email"
+	"coral.daniel-guo.com/internal/grouping"
 	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/retry"
+	"coral.daniel-guo.com/internal/templates"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"github.com/xuri/excelize/v2"
 )
 
 // MockEmailSender is a mock implementation of the email sender
@@ -20,11 +31,12 @@ type MockEmailSender struct {
 }
 
 func (m *MockEmailSender) SendWithAttachment(
+	_ context.Context,
 	sender, recipient, subject, body, attachmentName string,
 	attachmentContent []byte,
-) error {
+) (string, error) {
 	args := m.Called(sender, recipient, subject, body, attachmentName, attachmentContent)
-	return args.Error(0)
+	return args.String(0), args.Error(1)
 }
 
 // MockLocationRepository is a mock implementation of the location repository interface
@@ -32,7 +44,7 @@ type MockLocationRepository struct {
 	mock.Mock
 }
 
-func (m *MockLocationRepository) FindByName(name string) (*model.Location, error) {
+func (m *MockLocationRepository) FindByName(_ context.Context, name string) (*model.Location, error) {
 	args := m.Called(name)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -40,6 +52,22 @@ func (m *MockLocationRepository) FindByName(name string) (*model.Location, error
 	return args.Get(0).(*model.Location), args.Error(1)
 }
 
+func (m *MockLocationRepository) FindByNames(_ context.Context, names []string) (map[string]*model.Location, error) {
+	args := m.Called(names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*model.Location), args.Error(1)
+}
+
+func (m *MockLocationRepository) FindAll(_ context.Context) ([]*model.Location, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Location), args.Error(1)
+}
+
 type TransferServiceTestSuite struct {
 	suite.Suite
 	service          *Service
@@ -55,7 +83,6 @@ func (suite *TransferServiceTestSuite) SetupTest() {
 		DefaultSender:  "test@example.com",
 		TestEmail:      "",
 		WorkerPoolSize: 2,
-		WorkerDelayMs:  100,
 	}
 
 	suite.service = NewService(cfg)
@@ -73,6 +100,11 @@ func (suite *TransferServiceTestSuite) TearDownTest() {
 	_ = os.RemoveAll(suite.tempDir)
 }
 
+// request returns a transfer request for the given type with its period resolved
+func (suite *TransferServiceTestSuite) request(transferType string) TransferRequest {
+	return suite.service.withDefaults(TransferRequest{TransferType: transferType})
+}
+
 func (suite *TransferServiceTestSuite) createTestCSVFile(filename, content string) string {
 	filePath := filepath.Join(suite.tempDir, filename)
 	err := os.WriteFile(filePath, []byte(content), 0644)
@@ -80,6 +112,39 @@ func (suite *TransferServiceTestSuite) createTestCSVFile(filename, content strin
 	return filePath
 }
 
+// newTransferStore groups the given transfers by club in a store closed at the end of the test
+func (suite *TransferServiceTestSuite) newTransferStore(data map[string][]model.ClubTransferData) *grouping.Store {
+	store := grouping.NewStore(grouping.Config{})
+	suite.T().Cleanup(func() {
+		_ = store.Close()
+	})
+	for club, transfers := range data {
+		for _, transfer := range transfers {
+			require.NoError(suite.T(), store.Add(club, transfer))
+		}
+	}
+	return store
+}
+
+// clubTransfers returns the transfers of a club in the store
+func (suite *TransferServiceTestSuite) clubTransfers(store *grouping.Store, club string) []model.ClubTransferData {
+	transfers, err := store.Transfers(club)
+	require.NoError(suite.T(), err)
+	return transfers
+}
+
+// collectRows reads every row of the input file, stopping at the first error
+func (suite *TransferServiceTestSuite) collectRows(fileName, sheet string) ([]model.ClubTransferRow, error) {
+	var rows []model.ClubTransferRow
+	for row, err := range suite.service.clubTransferRows(fileName, sheet) {
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
 func (suite *TransferServiceTestSuite) TestNewService() {
 	cfg := &config.AppConfig{
 		Environment:   "test",
@@ -101,30 +166,77 @@ func (suite *TransferServiceTestSuite) TestReadClubTransferDataSuccess() {
 
 	filePath := suite.createTestCSVFile("test.csv", csvContent)
 
-	result, err := suite.service.readClubTransferData(filePath)
+	asOf := time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC)
+	result, err := suite.service.readClubTransferData(filePath, asOf)
 
-	assert.NoError(suite.T(), err)
-	assert.Len(suite.T(), result, 3) // CLUB A, CLUB B, CLUB C
+	require.NoError(suite.T(), err)
+	defer func() {
+		assert.NoError(suite.T(), result.Close())
+	}()
+	assert.Equal(suite.T(), []string{"CLUB A", "CLUB B", "CLUB C"}, result.Clubs())
 
 	// Check CLUB A (should have 1 transfer out and 1 transfer in)
-	clubATransfers := result["CLUB A"]
+	clubATransfers := suite.clubTransfers(result, "CLUB A")
 	assert.Len(suite.T(), clubATransfers, 2)
 
 	// Check CLUB B (should have 1 transfer in)
-	clubBTransfers := result["CLUB B"]
+	clubBTransfers := suite.clubTransfers(result, "CLUB B")
 	assert.Len(suite.T(), clubBTransfers, 1)
 	assert.Equal(suite.T(), "TRANSFER IN", clubBTransfers[0].TransferType)
 	assert.Equal(suite.T(), "12345", clubBTransfers[0].MemberID)
 
 	// Check CLUB C (should have 1 transfer out)
-	clubCTransfers := result["CLUB C"]
+	clubCTransfers := suite.clubTransfers(result, "CLUB C")
 	assert.Len(suite.T(), clubCTransfers, 1)
 	assert.Equal(suite.T(), "TRANSFER OUT", clubCTransfers[0].TransferType)
 	assert.Equal(suite.T(), "67890", clubCTransfers[0].MemberID)
+
+	// Every transfer is stamped with the as-of date
+	for _, club := range result.Clubs() {
+		for _, transfer := range suite.clubTransfers(result, club) {
+			assert.Equal(suite.T(), asOf, transfer.TransferDate)
+		}
+	}
 }
 
 func (suite *TransferServiceTestSuite) TestReadClubTransferDataFileNotFound() {
-	_, err := suite.service.readClubTransferData("nonexistent.csv")
+	_, err := suite.service.readClubTransferData("nonexistent.csv", time.Now())
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "error reading club transfer data")
+}
+
+func (suite *TransferServiceTestSuite) TestReadClubTransferRowsXLSX() {
+	f := excelize.NewFile()
+	defer func() {
+		_ = f.Close()
+	}()
+	_, err := f.NewSheet("Transfers")
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), f.SetSheetRow("Transfers", "A1", &[]interface{}{
+		"Member Id", "Fob Number", "First Name", "Last Name", "Membership Type", "Home Club", "Target Club",
+	}))
+	assert.NoError(suite.T(), f.SetSheetRow("Transfers", "A2", &[]interface{}{
+		12345, "FOB001", "John", "Doe", "Premium", "CLUB A", "club b",
+	}))
+	filePath := filepath.Join(suite.tempDir, "transfers.XLSX")
+	assert.NoError(suite.T(), f.SaveAs(filePath))
+
+	rows, err := suite.collectRows(filePath, "Transfers")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.ClubTransferRow{{
+		MemberID:       "12345",
+		FobNumber:      "FOB001",
+		FirstName:      "John",
+		LastName:       "Doe",
+		MembershipType: "Premium",
+		HomeClub:       "CLUB A",
+		TargetClub:     "CLUB B",
+		Line:           2,
+	}}, rows)
+
+	// The first sheet has no header row
+	_, err = suite.collectRows(filePath, "")
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "error reading club transfer data")
 }
@@ -144,6 +256,37 @@ func (suite *TransferServiceTestSuite) TestGetOutputFileNameOther() {
 	assert.Equal(suite.T(), "pif_club_transfer_CLUB_C.csv", result)
 }
 
+func (suite *TransferServiceTestSuite) TestGetOutputFileNameXLSX() {
+	service := NewService(&config.AppConfig{AttachmentFormat: config.AttachmentFormatXLSX})
+
+	assert.Equal(suite.T(), "dd_club_transfer_CLUB_B.xlsx", service.getOutputFileName("DD", "CLUB_B"))
+	assert.Equal(suite.T(), "pif_club_transfer_CLUB_A.xlsx", service.getOutputFileName("PIF", "CLUB_A"))
+}
+
+func (suite *TransferServiceTestSuite) TestGenerateAttachment() {
+	data := []model.ClubTransferData{{MemberID: "12345", TransferType: "TRANSFER IN", TransferDate: time.Now()}}
+
+	attachment, err := suite.service.generateAttachment("transfers.csv", data)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "text/csv", attachment.ContentType)
+	assert.Contains(suite.T(), string(attachment.Content), "12345")
+
+	service := NewService(&config.AppConfig{AttachmentFormat: "XLSX"})
+	attachment, err = service.generateAttachment("transfers.xlsx", data)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), xlsxContentType, attachment.ContentType)
+	// XLSX workbooks are zip archives
+	assert.Equal(suite.T(), []byte("PK"), attachment.Content[:2])
+
+	service = NewService(&config.AppConfig{AttachmentFormat: "pdf"})
+	_, err = service.generateAttachment("transfers.pdf", data)
+	assert.EqualError(suite.T(), err, "unsupported attachment format: pdf")
+	assert.Equal(suite.T(), []Problem{
+		{Message: `unsupported attachment format "pdf", expected csv or xlsx`},
+	}, service.validateAttachmentFormat())
+	assert.Empty(suite.T(), suite.service.validateAttachmentFormat())
+}
+
 func (suite *TransferServiceTestSuite) TestSendEmailSuccess() {
 	// Setup test data
 	transferData := []model.ClubTransferData{
@@ -171,46 +314,379 @@ func (suite *TransferServiceTestSuite) TestSendEmailSuccess() {
 		Email: "cluba@example.com",
 	}
 
-	suite.mockLocationRepo.On("FindByName", "CLUB A").Return(location, nil)
-
 	// Note: We can't easily test the actual email sending without more complex mocking
 	// of the email sender's internal AWS dependencies. In a real scenario, you'd
 	// inject the email sender as an interface and mock it here.
 
-	err := suite.service.sendEmail("CLUB A", data, "PIF", suite.mockLocationRepo)
+	_, err := suite.service.sendEmail(context.Background(), "CLUB A", data["CLUB A"], suite.request("PIF"), location)
 
 	// This will fail because we can't mock the email sender easily
 	// In a production setup, you'd refactor to inject dependencies
 	assert.Error(suite.T(), err)
-	suite.mockLocationRepo.AssertExpectations(suite.T())
 }
 
-func (suite *TransferServiceTestSuite) TestSendEmailLocationNotFound() {
+func (suite *TransferServiceTestSuite) TestSendEmailDryRun() {
+	dryRunDir := filepath.Join(suite.tempDir, "dry-run")
+	cfg := &config.AppConfig{
+		DefaultSender: "test@example.com",
+		TestEmail:     "tester@example.com",
+		DryRun:        true,
+		DryRunDir:     dryRunDir,
+	}
+	service := NewService(cfg)
+
 	data := map[string][]model.ClubTransferData{
-		"CLUB A": {},
+		"CLUB A": {{MemberID: "12345", TransferType: "TRANSFER IN", TransferDate: time.Now()}},
+	}
+	location := &model.Location{ID: "1", Name: "CLUB A", Email: "cluba@example.com"}
+	result, err := service.sendEmail(context.Background(), "CLUB A", data["CLUB A"], suite.request("DD"), location)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "CLUB A", result.ClubName)
+	assert.Equal(suite.T(), "cluba@example.com", result.Recipient)
+	assert.Equal(suite.T(), "tester@example.com", result.SentTo)
+	assert.Equal(suite.T(), "dd_club_transfer_CLUB A.csv", result.AttachmentName)
+	assert.Equal(suite.T(), 1, result.RowCount)
+	assert.Equal(suite.T(), 1, result.TransferInCount)
+	assert.Equal(suite.T(), 0, result.TransferOutCount)
+	assert.Equal(suite.T(), "1", result.LocationID)
+	assert.Contains(suite.T(), result.Subject, "Direct Debit")
+	assert.FileExists(suite.T(), result.MessageID)
+	assert.Equal(suite.T(), dryRunDir, filepath.Dir(result.MessageID))
+}
+
+func (suite *TransferServiceTestSuite) TestSendEmailMultipleRecipients() {
+	cfg := &config.AppConfig{
+		DefaultSender: "test@example.com",
+		DryRun:        true,
+		DryRunDir:     filepath.Join(suite.tempDir, "dry-run"),
+		Email:         email.Config{ReplyTo: []string{"members@example.com"}, Bcc: []string{"headoffice@example.com"}},
+	}
+	service := NewService(cfg)
+
+	data := map[string][]model.ClubTransferData{
+		"CLUB A": {{MemberID: "12345", TransferType: "TRANSFER IN", TransferDate: time.Now()}},
+	}
+	location := &model.Location{
+		ID:    "1",
+		Name:  "CLUB A",
+		Email: "manager@example.com, frontdesk@example.com; cc:owner@example.com",
+	}
+	result, err := service.sendEmail(context.Background(), "CLUB A", data["CLUB A"], suite.request("PIF"), location)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), location.Email, result.Recipient)
+	assert.Equal(suite.T(), "manager@example.com, frontdesk@example.com", result.SentTo)
+
+	content, err := os.ReadFile(result.MessageID)
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), string(content), "To: manager@example.com, frontdesk@example.com")
+	assert.Contains(suite.T(), string(content), "Cc: owner@example.com")
+	assert.Contains(suite.T(), string(content), "Reply-To: members@example.com")
+	assert.NotContains(suite.T(), string(content), "headoffice@example.com")
+
+	// Cc-only or malformed lists cannot be sent
+	location.Email = "cc:owner@example.com"
+	_, err = service.sendEmail(context.Background(), "CLUB A", data["CLUB A"], suite.request("PIF"), location)
+	assert.EqualError(suite.T(), err, "club CLUB A: email not found")
+
+	location.Email = "manager@example.com, not an email"
+	_, err = service.sendEmail(context.Background(), "CLUB A", data["CLUB A"], suite.request("PIF"), location)
+	assert.EqualError(suite.T(), err, `club CLUB A: invalid email address "not an email"`)
+}
+
+func (suite *TransferServiceTestSuite) TestSendEmailRendersClubTemplate() {
+	templateDir := filepath.Join(suite.tempDir, "templates")
+	clubDir := filepath.Join(templateDir, "pif", "clubs", "CLUB A")
+	assert.NoError(suite.T(), os.MkdirAll(clubDir, 0o755))
+	assert.NoError(suite.T(), os.WriteFile(
+		filepath.Join(clubDir, templates.SubjectFile),
+		[]byte("{{.ClubName}} has {{.TransferInCount}} new members"),
+		0o644,
+	))
+
+	cfg := &config.AppConfig{
+		DefaultSender: "test@example.com",
+		DryRun:        true,
+		DryRunDir:     filepath.Join(suite.tempDir, "dry-run"),
+		Templates:     templates.Config{Dir: templateDir},
+	}
+	service := NewService(cfg)
+
+	data := map[string][]model.ClubTransferData{
+		"CLUB A": {{MemberID: "12345", TransferType: "TRANSFER IN", TransferDate: time.Now()}},
+	}
+	location := &model.Location{ID: "1", Name: "CLUB A", Email: "cluba@example.com"}
+	result, err := service.sendEmail(context.Background(), "CLUB A", data["CLUB A"], suite.request("PIF"), location)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "CLUB A has 1 new members", result.Subject)
+}
+
+func (suite *TransferServiceTestSuite) TestSendEmailNamesLocation() {
+	templateDir := filepath.Join(suite.tempDir, "templates")
+	clubDir := filepath.Join(templateDir, "pif", "clubs", "St. Kilda")
+	assert.NoError(suite.T(), os.MkdirAll(clubDir, 0o755))
+	assert.NoError(suite.T(), os.WriteFile(
+		filepath.Join(clubDir, templates.SubjectFile),
+		[]byte("Transfers for {{.ClubName}}"),
+		0o644,
+	))
+
+	cfg := &config.AppConfig{
+		DefaultSender: "test@example.com",
+		DryRun:        true,
+		DryRunDir:     filepath.Join(suite.tempDir, "dry-run"),
+		Templates:     templates.Config{Dir: templateDir},
+	}
+	service := NewService(cfg)
+
+	data := []model.ClubTransferData{{MemberID: "12345", TransferType: "TRANSFER IN", TransferDate: time.Now()}}
+	location := &model.Location{ID: "1", Name: "St. Kilda", Email: "stkilda@example.com"}
+	result, err := service.sendEmail(context.Background(), "ST KILDA", data, suite.request("PIF"), location)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "ST KILDA", result.ClubName)
+	assert.Equal(suite.T(), "Transfers for St. Kilda", result.Subject)
+	assert.Equal(suite.T(), "pif_club_transfer_St. Kilda.csv", result.AttachmentName)
+}
+
+// flakyTransport fails with the queued errors before delivering messages
+type flakyTransport struct {
+	errs  []error
+	calls int
+}
+
+func (t *flakyTransport) Send(context.Context, string, []string, []byte) (string, error) {
+	t.calls++
+	if len(t.errs) > 0 {
+		err := t.errs[0]
+		t.errs = t.errs[1:]
+		return "", err
+	}
+	return "message-id", nil
+}
+
+func (suite *TransferServiceTestSuite) retryService(transport email.Transport) *Service {
+	service := NewService(&config.AppConfig{
+		DefaultSender: "test@example.com",
+		Retry:         retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	})
+	service.emailSender = email.NewSenderWithTransport(email.DefaultConfig(), transport)
+	return service
+}
+
+func (suite *TransferServiceTestSuite) TestSendEmailRetriesThrottling() {
+	transport := &flakyTransport{errs: []error{
+		awserr.New("Throttling", "Maximum sending rate exceeded.", nil),
+		awserr.New("Throttling", "Maximum sending rate exceeded.", nil),
+	}}
+	service := suite.retryService(transport)
+
+	data := map[string][]model.ClubTransferData{"CLUB A": {}}
+	location := &model.Location{ID: "1", Name: "CLUB A", Email: "cluba@example.com"}
+	result, err := service.sendEmail(context.Background(), "CLUB A", data["CLUB A"], suite.request("PIF"), location)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "message-id", result.MessageID)
+	assert.Equal(suite.T(), 3, transport.calls)
+}
+
+func (suite *TransferServiceTestSuite) TestSendEmailDoesNotRetryRejection() {
+	transport := &flakyTransport{errs: []error{
+		awserr.New(ses.ErrCodeMessageRejected, "Email address is not verified.", nil),
+	}}
+	service := suite.retryService(transport)
+
+	data := map[string][]model.ClubTransferData{"CLUB A": {}}
+	location := &model.Location{ID: "1", Name: "CLUB A", Email: "cluba@example.com"}
+	_, err := service.sendEmail(context.Background(), "CLUB A", data["CLUB A"], suite.request("PIF"), location)
+
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "not verified")
+	assert.Equal(suite.T(), 1, transport.calls)
+}
+
+func (suite *TransferServiceTestSuite) TestResolveLocationsRetriesTransientErrors() {
+	service := suite.retryService(&flakyTransport{})
+	location := &model.Location{ID: "1", Name: "CLUB A", Email: "cluba@example.com"}
+
+	suite.mockLocationRepo.On("FindByNames", []string{"CLUB A"}).
+		Return(nil, &pgconn.PgError{Code: "57P03"}).Once()
+	suite.mockLocationRepo.On("FindByNames", []string{"CLUB A"}).
+		Return(map[string]*model.Location{"CLUB A": location}, nil).Once()
+
+	locations, err := service.resolveLocations(context.Background(), []string{"CLUB A"}, suite.mockLocationRepo)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), location, locations["CLUB A"])
+	suite.mockLocationRepo.AssertNumberOfCalls(suite.T(), "FindByNames", 2)
+}
+
+// quotaTransport is a flaky transport reporting a sending quota
+type quotaTransport struct {
+	flakyTransport
+	rate float64
+	err  error
+}
+
+func (t *quotaTransport) MaxSendRate(context.Context) (float64, error) {
+	return t.rate, t.err
+}
+
+func (suite *TransferServiceTestSuite) TestNewRateLimiter() {
+	// Transports without a quota are not limited unless a rate is configured
+	service := suite.retryService(&flakyTransport{})
+	assert.Nil(suite.T(), service.newRateLimiter(context.Background()))
+
+	limiter := suite.retryService(&quotaTransport{rate: 14}).newRateLimiter(context.Background())
+	assert.Equal(suite.T(), 14.0, limiter.Rate())
+
+	limiter = suite.retryService(&quotaTransport{err: errors.New("access denied")}).newRateLimiter(context.Background())
+	assert.Equal(suite.T(), config.FallbackMaxRate, limiter.Rate())
+
+	service.config.MaxRate = 14
+	service.config.RateBurst = 3
+	limiter = service.newRateLimiter(context.Background())
+	assert.Equal(suite.T(), 14.0, limiter.Rate())
+	assert.Equal(suite.T(), 3, limiter.Burst())
+
+	service.config.DryRun = true
+	assert.Nil(suite.T(), service.newRateLimiter(context.Background()))
+}
+
+func (suite *TransferServiceTestSuite) TestReportingPeriod() {
+	now := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(suite.T(), "May 2025", suite.service.reportingPeriod("PIF", now).String())
+	assert.Equal(suite.T(), "March - May 2025", suite.service.reportingPeriod("DD", now).String())
+}
+
+func (suite *TransferServiceTestSuite) TestWithDefaults() {
+	asOf := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	req := suite.service.withDefaults(TransferRequest{TransferType: "DD", AsOf: asOf})
+	assert.Equal(suite.T(), asOf, req.AsOf)
+	assert.Equal(suite.T(), "March - May 2025", req.Period.String())
+
+	period := model.NewQuarterPeriod(2025, 1)
+	req = suite.service.withDefaults(TransferRequest{TransferType: "DD", Period: period, AsOf: asOf})
+	assert.Equal(suite.T(), period, req.Period)
+
+	req = suite.service.withDefaults(TransferRequest{TransferType: "PIF"})
+	assert.False(suite.T(), req.AsOf.IsZero())
+	assert.False(suite.T(), req.Period.IsZero())
+}
+
+func (suite *TransferServiceTestSuite) TestSendEmailUsesRequestPeriod() {
+	cfg := &config.AppConfig{
+		DefaultSender: "test@example.com",
+		DryRun:        true,
+		DryRunDir:     filepath.Join(suite.tempDir, "dry-run"),
+	}
+	service := NewService(cfg)
+
+	data := map[string][]model.ClubTransferData{"CLUB A": {}}
+	location := &model.Location{ID: "1", Name: "CLUB A", Email: "cluba@example.com"}
+	req := TransferRequest{
+		TransferType: "PIF",
+		Period:       model.NewMonthPeriod(2024, time.November),
+		AsOf:         time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC),
 	}
+	result, err := service.sendEmail(context.Background(), "CLUB A", data["CLUB A"], req, location)
 
-	suite.mockLocationRepo.On("FindByName", "CLUB A").Return(nil, nil)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Club Transfer for Paid in Full Members (November 2024)", result.Subject)
+}
 
-	err := suite.service.sendEmail("CLUB A", data, "PIF", suite.mockLocationRepo)
+func (suite *TransferServiceTestSuite) TestWriteDryRunSummary() {
+	dryRunDir := filepath.Join(suite.tempDir, "summary")
+	service := NewService(&config.AppConfig{DryRun: true, DryRunDir: dryRunDir})
+
+	results := []ClubResult{
+		{
+			ClubName:       "CLUB A",
+			Recipient:      "cluba@example.com",
+			SentTo:         "cluba@example.com",
+			AttachmentName: "pif_club_transfer_CLUB A.csv",
+			RowCount:       2,
+			MessageID:      "dry-run/1.eml",
+		},
+		{ClubName: "CLUB B", Err: errors.New("club CLUB B: location not found")},
+	}
+
+	err := service.writeDryRunSummary(results)
+	assert.NoError(suite.T(), err)
+
+	content, err := os.ReadFile(filepath.Join(dryRunDir, dryRunSummaryFile))
+	assert.NoError(suite.T(), err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(suite.T(), lines, 3)
+	assert.Contains(suite.T(), lines[0], "RECIPIENT")
+	assert.Contains(suite.T(), lines[1], "cluba@example.com")
+	assert.Contains(suite.T(), lines[1], "OK")
+	assert.Contains(suite.T(), lines[2], "location not found")
+}
+
+func (suite *TransferServiceTestSuite) TestSendEmailToClubsSkipsUnknownClubs() {
+	service := NewService(&config.AppConfig{
+		DefaultSender:  "test@example.com",
+		WorkerPoolSize: 2,
+		DryRun:         true,
+		DryRunDir:      filepath.Join(suite.tempDir, "dry-run"),
+	})
+	data := map[string][]model.ClubTransferData{
+		"CLUB A": {{MemberID: "1", TransferType: "TRANSFER IN"}},
+		"CLUB B": {{MemberID: "1", TransferType: "TRANSFER OUT"}},
+		"CLUB C": {{MemberID: "2", TransferType: "TRANSFER IN"}},
+	}
+	locations := map[string]*model.Location{
+		"CLUB A": {ID: "1", Name: "CLUB A", Email: "a@example.com"},
+		"CLUB C": {ID: "3", Name: "CLUB C", Email: "c@example.com"},
+	}
+	transfers := suite.newTransferStore(data)
+
+	results, err := service.sendEmailToClubs(
+		context.Background(), transfers, transfers.Clubs(), locations, suite.request("PIF"), nil)
 
 	assert.Error(suite.T(), err)
-	assert.Contains(suite.T(), err.Error(), "location not found")
-	suite.mockLocationRepo.AssertExpectations(suite.T())
+	assert.Contains(suite.T(), err.Error(), "failed to send emails to 1 clubs: [CLUB B]")
+	assert.Len(suite.T(), results, 3)
+	assert.NoError(suite.T(), results[0].Err)
+	assert.Error(suite.T(), results[1].Err)
+	assert.NoError(suite.T(), results[2].Err)
+	assert.FileExists(suite.T(), results[2].MessageID)
 }
 
-func (suite *TransferServiceTestSuite) TestSendEmailLocationError() {
+func (suite *TransferServiceTestSuite) TestSendEmailToClubsCancelled() {
+	service := NewService(&config.AppConfig{
+		DefaultSender:  "test@example.com",
+		WorkerPoolSize: 2,
+		DryRun:         true,
+		DryRunDir:      filepath.Join(suite.tempDir, "dry-run"),
+	})
 	data := map[string][]model.ClubTransferData{
-		"CLUB A": {},
+		"CLUB A": {{MemberID: "1", TransferType: "TRANSFER IN"}},
+		"CLUB B": {{MemberID: "2", TransferType: "TRANSFER OUT"}},
+	}
+	locations := map[string]*model.Location{
+		"CLUB A": {ID: "1", Name: "CLUB A", Email: "a@example.com"},
+		"CLUB B": {ID: "2", Name: "CLUB B", Email: "b@example.com"},
 	}
+	transfers := suite.newTransferStore(data)
 
-	suite.mockLocationRepo.On("FindByName", "CLUB A").Return(nil, errors.New("database error"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
 
-	err := suite.service.sendEmail("CLUB A", data, "PIF", suite.mockLocationRepo)
+	results, err := service.sendEmailToClubs(ctx, transfers, transfers.Clubs(), locations, suite.request("PIF"), nil)
 
 	assert.Error(suite.T(), err)
-	assert.Contains(suite.T(), err.Error(), "error finding location")
-	suite.mockLocationRepo.AssertExpectations(suite.T())
+	assert.Contains(suite.T(), err.Error(), "run cancelled with 2 clubs unsent")
+	assert.Len(suite.T(), results, 2)
+	for _, res := range results {
+		assert.ErrorIs(suite.T(), res.Err, ErrRunCancelled)
+		assert.Empty(suite.T(), res.MessageID)
+	}
 }
 
 func (suite *TransferServiceTestSuite) TestSendEmailNoEmail() {
@@ -224,13 +700,10 @@ func (suite *TransferServiceTestSuite) TestSendEmailNoEmail() {
 		Email: "", // No email
 	}
 
-	suite.mockLocationRepo.On("FindByName", "CLUB A").Return(location, nil)
-
-	err := suite.service.sendEmail("CLUB A", data, "PIF", suite.mockLocationRepo)
+	_, err := suite.service.sendEmail(context.Background(), "CLUB A", data["CLUB A"], suite.request("PIF"), location)
 
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "email not found")
-	suite.mockLocationRepo.AssertExpectations(suite.T())
 }
 
 func (suite *TransferServiceTestSuite) TestTransferRequestValidation() {
@@ -259,7 +732,7 @@ func (suite *TransferServiceTestSuite) TestProcessIntegration() {
 		FileName:     filePath,
 	}
 
-	err := suite.service.Process(req)
+	err := suite.service.Process(context.Background(), req)
 	// This will fail without proper database setup
 	assert.Error(suite.T(), err)
 }
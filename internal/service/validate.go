@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+
+	"coral.daniel-guo.com/internal/config"
+	"coral.daniel-guo.com/internal/csvutil"
+	"coral.daniel-guo.com/internal/grouping"
+	"coral.daniel-guo.com/internal/history"
+	"coral.daniel-guo.com/internal/logger"
+	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/repository"
+)
+
+// Problem is a single issue found during pre-flight validation
+type Problem struct {
+	// Club is the club the problem relates to, if any
+	Club string
+	// Line is the input file line the problem relates to, if any
+	Line int
+	// Message describes the problem
+	Message string
+}
+
+// String returns the problem prefixed with its club or line
+func (p Problem) String() string {
+	switch {
+	case p.Line > 0:
+		return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+	case p.Club != "":
+		return fmt.Sprintf("club %s: %s", p.Club, p.Message)
+	default:
+		return p.Message
+	}
+}
+
+// ValidationError is returned when pre-flight validation finds problems
+type ValidationError struct {
+	Problems []Problem
+}
+
+// Error lists every problem found
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("pre-flight validation failed with %d problems:", len(e.Problems)))
+	for _, problem := range e.Problems {
+		lines = append(lines, "  - "+problem.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// preparedRun holds everything resolved before any email is sent
+type preparedRun struct {
+	// rowCount is the number of rows read from the input file
+	rowCount int
+
+	// transfers are grouped by club and must be closed once the run is done
+	transfers *grouping.Store
+
+	// history records the transfers notified by previous runs, if configured
+	history *history.History
+
+	locations map[string]*model.Location
+	problems  []Problem
+
+	// rejects are the invalid and duplicate rows skipped from the run
+	rejects []csvutil.Reject
+}
+
+// Validate runs the pre-flight checks for a request without sending any email
+func (s *Service) Validate(ctx context.Context, req TransferRequest) error {
+	req = s.withDefaults(req)
+
+	db, err := s.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	run, err := s.prepare(ctx, req, repository.NewLocationRepository(db))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = run.transfers.Close()
+	}()
+	if len(run.rejects) > 0 {
+		if err := s.writeRejects(run.rejects); err != nil {
+			logger.Error("Failed to write rejected rows: %v", err)
+		}
+	}
+	if len(run.problems) > 0 {
+		return &ValidationError{Problems: run.problems}
+	}
+
+	logger.Info("Validation passed: %d rows for %d clubs", run.rowCount, run.transfers.Len())
+	return nil
+}
+
+// prepare streams the input file into per-club transfers, resolves every
+// club location and runs the pre-flight checks. Problems are collected rather
+// than returned as errors so that every issue can be reported at once. The
+// run's transfers must be closed.
+func (s *Service) prepare(
+	ctx context.Context,
+	req TransferRequest,
+	locationRepo repository.LocationRepositoryInterface,
+) (_ *preparedRun, err error) {
+	transfers := grouping.NewStore(s.config.Grouping)
+	defer func() {
+		if err != nil {
+			_ = transfers.Close()
+		}
+	}()
+	run := &preparedRun{transfers: transfers}
+
+	duplicates, err := s.newDuplicateChecker(req)
+	if err != nil {
+		return nil, err
+	}
+	run.history = duplicates.history
+
+	var invalid, skippedDuplicates []csvutil.Reject
+	var duplicateProblems []Problem
+	for row, err := range s.clubTransferRows(req.FileName, req.Sheet) {
+		if err != nil {
+			return nil, err
+		}
+		run.rowCount++
+		if reasons := s.validateRow(row); len(reasons) > 0 {
+			// Invalid rows are reported by their line and never grouped, so
+			// they neither add clubs to the run nor reach dry run attachments
+			invalid = append(invalid, csvutil.Reject{Row: row, Reason: strings.Join(reasons, "; ")})
+			continue
+		}
+		if reason, pending := duplicates.check(row); reason != "" {
+			switch s.onDuplicate() {
+			case config.OnDuplicateSkip:
+				if len(pending) > 0 {
+					// Only the club already notified is left out
+					logger.Warn("Line %d: %s, notifying %s only", row.Line, reason, pending[0])
+					if err := s.addTransfer(run.transfers, row, req.AsOf, pending[0]); err != nil {
+						return nil, fmt.Errorf("failed to group transfers: %w", err)
+					}
+					continue
+				}
+				logger.Warn("Skipping line %d: %s", row.Line, reason)
+				skippedDuplicates = append(skippedDuplicates, csvutil.Reject{Row: row, Reason: reason})
+				continue
+			case config.OnDuplicateFail:
+				duplicateProblems = append(duplicateProblems, Problem{Line: row.Line, Message: reason})
+			default:
+				logger.Warn("Line %d: %s", row.Line, reason)
+			}
+		}
+		if err := s.addTransfers(run.transfers, row, req.AsOf); err != nil {
+			return nil, fmt.Errorf("failed to group transfers: %w", err)
+		}
+	}
+	logger.Info("Successfully read %d rows from %s", run.rowCount, req.FileName)
+	if run.transfers.Spilled() {
+		logger.Info("Spilled transfers of %d clubs to temporary files", run.transfers.Len())
+	}
+
+	run.rejects, run.problems = s.checkRows(run.rowCount, invalid)
+	run.rejects = append(run.rejects, skippedDuplicates...)
+	sort.SliceStable(run.rejects, func(i, j int) bool {
+		return run.rejects[i].Row.Line < run.rejects[j].Row.Line
+	})
+	run.problems = append(run.problems, duplicateProblems...)
+	sort.SliceStable(run.problems, func(i, j int) bool {
+		return run.problems[i].Line < run.problems[j].Line
+	})
+
+	clubs := run.transfers.Clubs()
+	run.locations, err = s.resolveLocations(ctx, clubs, locationRepo)
+	if err != nil {
+		return nil, err
+	}
+	suggestions := s.suggestLocations(ctx, clubs, run.locations, locationRepo)
+	if s.config.ConfirmAliases && len(suggestions) > 0 {
+		if err := s.confirmAliases(clubs, suggestions, run.locations); err != nil {
+			return nil, err
+		}
+		if clubs, err = s.mergeClubs(run); err != nil {
+			return nil, err
+		}
+	}
+
+	run.problems = append(run.problems, s.validateSender()...)
+	run.problems = append(run.problems, s.validateTransport()...)
+	run.problems = append(run.problems, s.validateAttachmentFormat()...)
+	run.problems = append(run.problems, s.validateOnDuplicate()...)
+	run.problems = append(run.problems, validateLocations(clubs, run.locations, suggestions)...)
+
+	return run, nil
+}
+
+// validateRow checks a row against the input columns and returns the reasons
+// it is invalid, if any. Home and target clubs are compared by their keys, so
+// spellings of the same club or names aliased to the same location match.
+func (s *Service) validateRow(row model.ClubTransferRow) []string {
+	reasons := s.config.Input.ValidateRow(row)
+	if home := s.clubKey(row.HomeClub); home != "" && home == s.clubKey(row.TargetClub) {
+		reasons = append(reasons, fmt.Sprintf("Home Club and Target Club are both %s", home))
+	}
+	return reasons
+}
+
+// validateSender checks the configured sender and test email addresses
+func (s *Service) validateSender() []Problem {
+	var problems []Problem
+	if _, err := mail.ParseAddress(s.config.DefaultSender); err != nil {
+		problems = append(problems, Problem{
+			Message: fmt.Sprintf("invalid sender address %q: %v", s.config.DefaultSender, err),
+		})
+	}
+	if s.config.TestEmail != "" {
+		if _, err := mail.ParseAddress(s.config.TestEmail); err != nil {
+			problems = append(problems, Problem{
+				Message: fmt.Sprintf("invalid test email address %q: %v", s.config.TestEmail, err),
+			})
+		}
+	}
+	return problems
+}
+
+// validateTransport checks the configured mail transport could be created, so
+// that a misconfigured transport fails before any club is sent
+func (s *Service) validateTransport() []Problem {
+	if err := s.emailSender.Err(); err != nil {
+		return []Problem{{Message: err.Error()}}
+	}
+	return nil
+}
+
+// validateAttachmentFormat checks the configured attachment format is supported
+func (s *Service) validateAttachmentFormat() []Problem {
+	switch s.attachmentFormat() {
+	case config.AttachmentFormatCSV, config.AttachmentFormatXLSX:
+		return nil
+	}
+	return []Problem{{
+		Message: fmt.Sprintf("unsupported attachment format %q, expected csv or xlsx", s.config.AttachmentFormat),
+	}}
+}
+
+// checkRows reports the invalid rows out of rowCount as problems, or when
+// SkipInvalidRows is set, returns them as rejects with a problem only if
+// there are more than MaxInvalidPercent of them
+func (s *Service) checkRows(rowCount int, invalid []csvutil.Reject) ([]csvutil.Reject, []Problem) {
+	if !s.config.SkipInvalidRows {
+		problems := make([]Problem, 0, len(invalid))
+		for _, reject := range invalid {
+			problems = append(problems, Problem{Line: reject.Row.Line, Message: reject.Reason})
+		}
+		return nil, problems
+	}
+
+	for _, reject := range invalid {
+		logger.Warn("Skipping line %d: %s", reject.Row.Line, reject.Reason)
+	}
+	if rowCount == 0 {
+		return invalid, nil
+	}
+	percent := 100 * float64(len(invalid)) / float64(rowCount)
+	if percent > s.config.MaxInvalidPercent {
+		return invalid, []Problem{{
+			Message: fmt.Sprintf("%d of %d rows are invalid (%.1f%%), more than the %.1f%% allowed",
+				len(invalid), rowCount, percent, s.config.MaxInvalidPercent),
+		}}
+	}
+	return invalid, nil
+}
+
+// validateLocations checks every club resolves to a location with valid
+// recipient addresses, suggesting the closest locations for clubs without one
+func validateLocations(
+	clubs []string,
+	locations map[string]*model.Location,
+	suggestions map[string][]*model.Location,
+) []Problem {
+	var problems []Problem
+	for _, club := range clubs {
+		location, ok := locations[club]
+		switch {
+		case !ok && len(suggestions[club]) > 0:
+			problems = append(problems, Problem{
+				Club:    club,
+				Message: "location not found, did you mean " + locationNames(suggestions[club]) + "?",
+			})
+		case !ok:
+			problems = append(problems, Problem{Club: club, Message: "location not found"})
+		case strings.TrimSpace(location.Email) == "":
+			problems = append(problems, Problem{Club: club, Message: "email not found"})
+		default:
+			recipients, err := location.Recipients()
+			switch {
+			case err != nil:
+				problems = append(problems, Problem{Club: club, Message: err.Error()})
+			case len(recipients.To) == 0:
+				problems = append(problems, Problem{Club: club, Message: "no To recipient, only cc/bcc addresses"})
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"coral.daniel-guo.com/internal/config"
+	"coral.daniel-guo.com/internal/csvutil"
+	"coral.daniel-guo.com/internal/email"
+	"coral.daniel-guo.com/internal/grouping"
+	"coral.daniel-guo.com/internal/locations"
+	"coral.daniel-guo.com/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *TransferServiceTestSuite) TestPrepareCollectsAllProblems() {
+	csvContent := `Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club
+12345,FOB001,John,Doe,Premium,CLUB A,CLUB B
+,FOB002,Jane,,Standard,CLUB E,CLUB A
+11111,FOB003,Bob,Johnson,Basic,CLUB C,CLUB D`
+	req := suite.request("PIF")
+	req.FileName = suite.createTestCSVFile("preflight.csv", csvContent)
+
+	suite.mockLocationRepo.On("FindByNames", []string{"CLUB A", "CLUB B", "CLUB C", "CLUB D"}).
+		Return(map[string]*model.Location{
+			"CLUB A": {ID: "1", Name: "CLUB A", Email: "a@example.com"},
+			"CLUB B": {ID: "2", Name: "CLUB B", Email: ""},
+			"CLUB C": {ID: "3", Name: "CLUB C", Email: "not an email"},
+		}, nil)
+	suite.mockLocationRepo.On("FindAll").Return([]*model.Location{{ID: "9", Name: "Melbourne Central"}}, nil)
+
+	run, err := suite.service.prepare(context.Background(), req, suite.mockLocationRepo)
+
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, run.rowCount)
+	// The invalid line adds no clubs and no transfers
+	assert.Equal(suite.T(), []string{"CLUB A", "CLUB B", "CLUB C", "CLUB D"}, run.transfers.Clubs())
+	assert.Equal(suite.T(), 1, run.transfers.Count("CLUB A"))
+	assert.Len(suite.T(), run.locations, 3)
+	assert.Equal(suite.T(), []Problem{
+		{Line: 3, Message: "missing Member Id, Last Name"},
+		{Club: "CLUB B", Message: "email not found"},
+		{Club: "CLUB C", Message: `invalid email address "not an email"`},
+		{Club: "CLUB D", Message: "location not found"},
+	}, run.problems)
+	suite.mockLocationRepo.AssertExpectations(suite.T())
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareResolvesLocationAliases() {
+	csvContent := `Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club
+12345,FOB001,John,Doe,Premium,St. Kilda,CBD
+22222,FOB002,Amy,Lee,Basic,ST KILDA BEACH,CBD`
+	req := suite.request("PIF")
+	req.FileName = suite.createTestCSVFile("aliases.csv", csvContent)
+
+	aliases := locations.Aliases{}
+	require.NoError(suite.T(), aliases.Add("cbd", "Sydney CBD"))
+	require.NoError(suite.T(), aliases.Add("St Kilda Beach", "St. Kilda"))
+	service := NewService(&config.AppConfig{
+		DefaultSender:   "test@example.com",
+		WorkerPoolSize:  2,
+		DryRun:          true,
+		DryRunDir:       filepath.Join(suite.tempDir, "dry-run"),
+		LocationAliases: aliases,
+	})
+	suite.mockLocationRepo.On("FindByNames", []string{"ST KILDA", "SYDNEY CBD"}).
+		Return(map[string]*model.Location{
+			"SYDNEY CBD": {ID: "1", Name: "Sydney CBD", Email: "cbd@example.com"},
+			"ST KILDA":   {ID: "2", Name: "St. Kilda", Email: "stkilda@example.com"},
+		}, nil)
+
+	run, err := service.prepare(context.Background(), req, suite.mockLocationRepo)
+	require.NoError(suite.T(), err)
+	defer func() {
+		_ = run.transfers.Close()
+	}()
+
+	assert.Empty(suite.T(), run.problems)
+	// Every spelling of a location is grouped together
+	assert.Equal(suite.T(), []string{"ST KILDA", "SYDNEY CBD"}, run.transfers.Clubs())
+	assert.Equal(suite.T(), 2, run.transfers.Count("ST KILDA"))
+	assert.Equal(suite.T(), "2", run.locations["ST KILDA"].ID)
+	suite.mockLocationRepo.AssertExpectations(suite.T())
+
+	results, err := service.sendEmailToClubs(
+		context.Background(), run.transfers, run.transfers.Clubs(), run.locations, req, nil)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), results, 2)
+	assert.Equal(suite.T(), "ST KILDA", results[0].ClubName)
+	assert.Equal(suite.T(), 2, results[0].RowCount)
+	assert.Equal(suite.T(), "stkilda@example.com", results[0].Recipient)
+}
+
+func (suite *TransferServiceTestSuite) TestValidateLocationsRecipients() {
+	problems := validateLocations([]string{"CLUB A", "CLUB B", "CLUB C"}, map[string]*model.Location{
+		"CLUB A": {Name: "CLUB A", Email: "manager@example.com; cc:owner@example.com; bcc:audit@example.com"},
+		"CLUB B": {Name: "CLUB B", Email: "manager@example.com, not an email"},
+		"CLUB C": {Name: "CLUB C", Email: "cc:owner@example.com"},
+	}, nil)
+
+	assert.Equal(suite.T(), []Problem{
+		{Club: "CLUB B", Message: `invalid email address "not an email"`},
+		{Club: "CLUB C", Message: "no To recipient, only cc/bcc addresses"},
+	}, problems)
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareRejectsRowsWithinOneLocation() {
+	csvContent := `Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club
+12345,FOB001,John,Doe,Premium,St. Kilda,Sydney CBD
+67890,FOB002,Jane,Smith,Standard,St. Kilda,ST KILDA
+11111,FOB003,Bob,Johnson,Basic,CBD,Sydney CBD`
+	req := suite.request("PIF")
+	req.FileName = suite.createTestCSVFile("same.csv", csvContent)
+	suite.mockLocationRepo.On("FindByNames", []string{"ST KILDA", "SYDNEY CBD"}).
+		Return(map[string]*model.Location{
+			"ST KILDA":   {ID: "1", Name: "St. Kilda", Email: "stkilda@example.com"},
+			"SYDNEY CBD": {ID: "2", Name: "Sydney CBD", Email: "cbd@example.com"},
+		}, nil)
+
+	suite.service.config.LocationAliases = locations.Aliases{"CBD": "Sydney CBD"}
+	suite.service.config.SkipInvalidRows = true
+	suite.service.config.MaxInvalidPercent = 100
+	run, err := suite.service.prepare(context.Background(), req, suite.mockLocationRepo)
+
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), run.problems)
+	require.Len(suite.T(), run.rejects, 2)
+	assert.Equal(suite.T(), "Home Club and Target Club are both ST KILDA", run.rejects[0].Reason)
+	assert.Equal(suite.T(), "Home Club and Target Club are both SYDNEY CBD", run.rejects[1].Reason)
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareSkipsInvalidRows() {
+	csvContent := `Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club
+12345,FOB001,John,Doe,Premium,CLUB A,CLUB B
+
+,FOB002,Jane,Smith,Standard,CLUB C,CLUB A
+11111,FOB003,Bob,Johnson,Basic,CLUB B,club b
+22222,FOB004,Amy,Lee,Basic,CLUB B,CLUB A`
+	req := suite.request("PIF")
+	req.FileName = suite.createTestCSVFile("skip.csv", csvContent)
+	suite.mockLocationRepo.On("FindByNames", []string{"CLUB A", "CLUB B"}).
+		Return(map[string]*model.Location{
+			"CLUB A": {ID: "1", Name: "CLUB A", Email: "a@example.com"},
+			"CLUB B": {ID: "2", Name: "CLUB B", Email: "b@example.com"},
+		}, nil)
+
+	suite.service.config.SkipInvalidRows = true
+	suite.service.config.MaxInvalidPercent = 50
+	run, err := suite.service.prepare(context.Background(), req, suite.mockLocationRepo)
+
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), run.problems)
+	assert.Equal(suite.T(), 4, run.rowCount)
+	assert.Equal(suite.T(), []string{"CLUB A", "CLUB B"}, run.transfers.Clubs())
+	assert.Equal(suite.T(), 2, run.transfers.Count("CLUB B"))
+	require.Len(suite.T(), run.rejects, 2)
+	assert.Equal(suite.T(), 4, run.rejects[0].Row.Line)
+	assert.Equal(suite.T(), "missing Member Id", run.rejects[0].Reason)
+	assert.Equal(suite.T(), 5, run.rejects[1].Row.Line)
+	assert.Equal(suite.T(), "Home Club and Target Club are both CLUB B", run.rejects[1].Reason)
+
+	// Too many invalid rows still fail the run
+	suite.service.config.MaxInvalidPercent = 25
+	run, err = suite.service.prepare(context.Background(), req, suite.mockLocationRepo)
+
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []Problem{
+		{Message: "2 of 4 rows are invalid (50.0%), more than the 25.0% allowed"},
+	}, run.problems)
+	assert.Len(suite.T(), run.rejects, 2)
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareSpillsTransfers() {
+	var csvContent strings.Builder
+	csvContent.WriteString("Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&csvContent, "%d,FOB%d,First,Last,Basic,CLUB %c,CLUB Z\n", i, i, 'A'+i%4)
+	}
+	req := suite.request("PIF")
+	req.FileName = suite.createTestCSVFile("large.csv", csvContent.String())
+	suite.mockLocationRepo.On("FindByNames", []string{"CLUB A", "CLUB B", "CLUB C", "CLUB D", "CLUB Z"}).
+		Return(map[string]*model.Location{}, nil)
+	suite.mockLocationRepo.On("FindAll").Return([]*model.Location{}, nil)
+
+	spillDir := filepath.Join(suite.tempDir, "spill")
+	require.NoError(suite.T(), os.Mkdir(spillDir, 0755))
+	suite.service.config.Grouping = grouping.Config{MaxInMemory: 8, Dir: spillDir}
+	run, err := suite.service.prepare(context.Background(), req, suite.mockLocationRepo)
+
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), run.transfers.Spilled())
+	assert.Equal(suite.T(), 20, run.rowCount)
+	assert.Equal(suite.T(), 20, run.transfers.Count("CLUB Z"))
+
+	transfers := suite.clubTransfers(run.transfers, "CLUB B")
+	require.Len(suite.T(), transfers, 5)
+	for i, transfer := range transfers {
+		assert.Equal(suite.T(), fmt.Sprint(4*i+1), transfer.MemberID)
+		assert.Equal(suite.T(), "TRANSFER OUT", transfer.TransferType)
+		assert.True(suite.T(), transfer.TransferDate.Equal(req.AsOf))
+	}
+
+	require.NoError(suite.T(), run.transfers.Close())
+	entries, err := os.ReadDir(spillDir)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), entries)
+}
+
+func (suite *TransferServiceTestSuite) TestWriteRejects() {
+	rejects := []csvutil.Reject{{Row: model.ClubTransferRow{MemberID: "12345", Line: 3}, Reason: "missing Fob Number"}}
+
+	// Without a path the rejects are only logged
+	assert.NoError(suite.T(), suite.service.writeRejects(rejects))
+
+	suite.service.config.RejectsPath = filepath.Join(suite.tempDir, "rejects.csv")
+	assert.NoError(suite.T(), suite.service.writeRejects(rejects))
+
+	content, err := os.ReadFile(suite.service.config.RejectsPath)
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), string(content), "3,missing Fob Number,12345")
+}
+
+func (suite *TransferServiceTestSuite) TestPrepareErrors() {
+	req := suite.request("PIF")
+	req.FileName = "nonexistent.csv"
+
+	_, err := suite.service.prepare(context.Background(), req, suite.mockLocationRepo)
+	assert.Error(suite.T(), err)
+
+	req.FileName = suite.createTestCSVFile("ok.csv", `Member Id,Fob Number,First Name,Last Name,Membership Type,Home Club,Target Club
+12345,FOB001,John,Doe,Premium,CLUB A,CLUB B`)
+	suite.mockLocationRepo.On("FindByNames", []string{"CLUB A", "CLUB B"}).Return(nil, errors.New("database error"))
+
+	_, err = suite.service.prepare(context.Background(), req, suite.mockLocationRepo)
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "error finding locations")
+}
+
+func (suite *TransferServiceTestSuite) TestValidateSender() {
+	service := NewService(&config.AppConfig{DefaultSender: "no-reply@the-hub.ai"})
+	assert.Empty(suite.T(), service.validateSender())
+
+	service = NewService(&config.AppConfig{DefaultSender: "no-reply", TestEmail: "tester@@example.com"})
+	problems := service.validateSender()
+	assert.Len(suite.T(), problems, 2)
+	assert.Contains(suite.T(), problems[0].Message, `invalid sender address "no-reply"`)
+	assert.Contains(suite.T(), problems[1].Message, `invalid test email address "tester@@example.com"`)
+}
+
+func (suite *TransferServiceTestSuite) TestValidateTransport() {
+	service := NewService(&config.AppConfig{Email: email.Config{Transport: email.TransportSMTP}})
+	assert.Equal(suite.T(), []Problem{{Message: "smtp host is not configured"}}, service.validateTransport())
+
+	// Dry runs write every message to disk whatever the transport
+	service = NewService(&config.AppConfig{Email: email.Config{Transport: "pigeon"}, DryRun: true})
+	assert.Empty(suite.T(), service.validateTransport())
+}
+
+func (suite *TransferServiceTestSuite) TestValidationErrorListsProblems() {
+	err := &ValidationError{Problems: []Problem{
+		{Message: "invalid sender address"},
+		{Line: 4, Message: "missing Member Id"},
+		{Club: "CLUB D", Message: "location not found"},
+	}}
+
+	assert.Equal(suite.T(), "pre-flight validation failed with 3 problems:\n"+
+		"  - invalid sender address\n"+
+		"  - line 4: missing Member Id\n"+
+		"  - club CLUB D: location not found", err.Error())
+}
@@ -0,0 +1,151 @@
+// Package templates renders email subjects and bodies from template files
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"coral.daniel-guo.com/internal/model"
+)
+
+// Template file names looked up for every transfer type
+const (
+	SubjectFile = "subject.txt.tmpl"
+	BodyFile    = "body.html.tmpl"
+)
+
+//go:embed default
+var defaultTemplates embed.FS
+
+// Config contains template configuration
+type Config struct {
+	// Dir optionally overrides the built-in templates. Templates are looked up as
+	// <Dir>/<type>/clubs/<club name>/<file> first, then <Dir>/<type>/<file>.
+	Dir string
+}
+
+// Data is the data available to subject and body templates
+type Data struct {
+	ClubName         string
+	TransferType     string
+	Period           model.Period
+	AsOf             time.Time
+	RowCount         int
+	TransferInCount  int
+	TransferOutCount int
+	Transfers        []model.ClubTransferData
+}
+
+// NewData builds template data for a club, counting its incoming and outgoing transfers
+func NewData(clubName, transferType string, period model.Period, transfers []model.ClubTransferData) Data {
+	data := Data{
+		ClubName:     clubName,
+		TransferType: transferType,
+		Period:       period,
+		RowCount:     len(transfers),
+		Transfers:    transfers,
+	}
+	for _, transfer := range transfers {
+		switch transfer.TransferType {
+		case "TRANSFER IN":
+			data.TransferInCount++
+		case "TRANSFER OUT":
+			data.TransferOutCount++
+		}
+	}
+	return data
+}
+
+// Renderer renders email subjects and bodies
+type Renderer struct {
+	config Config
+}
+
+// NewRenderer creates a new renderer with the given configuration
+func NewRenderer(config Config) *Renderer {
+	return &Renderer{config: config}
+}
+
+// funcs are the helper functions available to all templates
+var funcs = map[string]any{
+	"formatDate": func(layout string, t time.Time) string { return t.Format(layout) },
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+}
+
+// Render renders the subject and HTML body for the given data
+func (r *Renderer) Render(data Data) (string, string, error) {
+	subjectSource, subjectName, err := r.load(data.TransferType, data.ClubName, SubjectFile)
+	if err != nil {
+		return "", "", err
+	}
+	subjectTmpl, err := texttemplate.New(subjectName).Funcs(funcs).Parse(subjectSource)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse template %s: %w", subjectName, err)
+	}
+	var subject bytes.Buffer
+	if err := subjectTmpl.Execute(&subject, data); err != nil {
+		return "", "", fmt.Errorf("failed to render template %s: %w", subjectName, err)
+	}
+
+	bodySource, bodyName, err := r.load(data.TransferType, data.ClubName, BodyFile)
+	if err != nil {
+		return "", "", err
+	}
+	bodyTmpl, err := htmltemplate.New(bodyName).Funcs(funcs).Parse(bodySource)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse template %s: %w", bodyName, err)
+	}
+	var body bytes.Buffer
+	if err := bodyTmpl.Execute(&body, data); err != nil {
+		return "", "", fmt.Errorf("failed to render template %s: %w", bodyName, err)
+	}
+
+	// Subjects are single line, so collapse any newlines left by the template file
+	return strings.Join(strings.Fields(subject.String()), " "), body.String(), nil
+}
+
+// load returns the most specific template source for the club and its name
+func (r *Renderer) load(transferType, clubName, file string) (string, string, error) {
+	set := templateSet(transferType)
+
+	if r.config.Dir != "" {
+		candidates := []string{
+			filepath.Join(r.config.Dir, set, "clubs", clubName, file),
+			filepath.Join(r.config.Dir, set, file),
+		}
+		for _, path := range candidates {
+			content, err := os.ReadFile(path)
+			if err == nil {
+				return string(content), path, nil
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return "", "", fmt.Errorf("failed to read template %s: %w", path, err)
+			}
+		}
+	}
+
+	path := "default/" + set + "/" + file
+	content, err := defaultTemplates.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read default template %s: %w", path, err)
+	}
+	return string(content), path, nil
+}
+
+// templateSet returns the template directory for a transfer type
+func templateSet(transferType string) string {
+	if transferType == "PIF" {
+		return "pif"
+	}
+	return "dd"
+}
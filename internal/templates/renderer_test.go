@@ -0,0 +1,121 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"coral.daniel-guo.com/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplate(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func testTransfers() []model.ClubTransferData {
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	return []model.ClubTransferData{
+		{MemberID: "1", FirstName: "John", TransferType: "TRANSFER IN", TransferDate: date},
+		{MemberID: "2", FirstName: "Jane", TransferType: "TRANSFER OUT", TransferDate: date},
+		{MemberID: "3", FirstName: "Bob", TransferType: "TRANSFER IN", TransferDate: date},
+	}
+}
+
+func TestNewData(t *testing.T) {
+	period := model.NewMonthPeriod(2025, time.May)
+
+	data := NewData("CLUB A", "PIF", period, testTransfers())
+
+	assert.Equal(t, "CLUB A", data.ClubName)
+	assert.Equal(t, "PIF", data.TransferType)
+	assert.Equal(t, period, data.Period)
+	assert.Equal(t, 3, data.RowCount)
+	assert.Equal(t, 2, data.TransferInCount)
+	assert.Equal(t, 1, data.TransferOutCount)
+}
+
+func TestRenderer_RenderDefaults(t *testing.T) {
+	renderer := NewRenderer(Config{})
+
+	t.Run("PIF", func(t *testing.T) {
+		data := NewData("CLUB A", "PIF", model.NewMonthPeriod(2025, time.May), nil)
+
+		subject, body, err := renderer.Render(data)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Club Transfer for Paid in Full Members (May 2025)", subject)
+		assert.Contains(t, body, "<p>Please find attached the Paid in Full club transfer data for your club (May 2025).</p>")
+	})
+
+	t.Run("DD", func(t *testing.T) {
+		period := model.LastQuarter(time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC))
+		data := NewData("CLUB A", "DD", period, nil)
+
+		subject, body, err := renderer.Render(data)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Club Transfer for Direct Debit Members (March - May 2025)", subject)
+		assert.Contains(t, body, "Direct Debit club transfer data for your club (March - May 2025)")
+	})
+}
+
+func TestRenderer_RenderOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "pif", SubjectFile), "{{.ClubName}}: {{.RowCount}} transfers\n")
+	writeTemplate(t, filepath.Join(dir, "pif", BodyFile),
+		`<p>{{.TransferInCount}} in, {{.TransferOutCount}} out</p>{{range .Transfers}}<li>{{.FirstName}}</li>{{end}}`)
+	writeTemplate(t, filepath.Join(dir, "pif", "clubs", "CLUB B", BodyFile), `<p>Custom for {{.ClubName}}</p>`)
+
+	renderer := NewRenderer(Config{Dir: dir})
+	period := model.NewMonthPeriod(2025, time.May)
+
+	t.Run("type level override", func(t *testing.T) {
+		subject, body, err := renderer.Render(NewData("CLUB A", "PIF", period, testTransfers()))
+
+		require.NoError(t, err)
+		assert.Equal(t, "CLUB A: 3 transfers", subject)
+		assert.Equal(t, "<p>2 in, 1 out</p><li>John</li><li>Jane</li><li>Bob</li>", body)
+	})
+
+	t.Run("club level override", func(t *testing.T) {
+		subject, body, err := renderer.Render(NewData("CLUB B", "PIF", period, testTransfers()))
+
+		require.NoError(t, err)
+		assert.Equal(t, "CLUB B: 3 transfers", subject)
+		assert.Equal(t, "<p>Custom for CLUB B</p>", body)
+	})
+
+	t.Run("falls back to defaults for other types", func(t *testing.T) {
+		subject, _, err := renderer.Render(NewData("CLUB A", "DD", period, nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, "Club Transfer for Direct Debit Members (May 2025)", subject)
+	})
+}
+
+func TestRenderer_RenderEscapesHTML(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "pif", BodyFile), `<p>{{.ClubName}}</p>`)
+	renderer := NewRenderer(Config{Dir: dir})
+
+	_, body, err := renderer.Render(NewData("<b>O'Connor</b>", "PIF", model.NewMonthPeriod(2025, time.May), nil))
+
+	require.NoError(t, err)
+	assert.Equal(t, "<p>&lt;b&gt;O&#39;Connor&lt;/b&gt;</p>", body)
+}
+
+func TestRenderer_RenderInvalidTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "dd", SubjectFile), "{{.ClubName")
+	renderer := NewRenderer(Config{Dir: dir})
+
+	_, _, err := renderer.Render(NewData("CLUB A", "DD", model.NewMonthPeriod(2025, time.May), nil))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse template")
+}
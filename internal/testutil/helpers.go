@@ -47,7 +47,6 @@ func CreateTestConfig() *config.AppConfig {
 		DefaultSender:  "test@example.com",
 		TestEmail:      "",
 		WorkerPoolSize: 2,
-		WorkerDelayMs:  100,
 		Email: email.Config{
 			Region: "us-east-1",
 		},
@@ -0,0 +1,231 @@
+// Package xlsxutil provides utilities for working with Excel (XLSX) workbooks
+package xlsxutil
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"coral.daniel-guo.com/internal/csvutil"
+	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/schema"
+	"github.com/xuri/excelize/v2"
+)
+
+// sheetOrder lists the transfer types that always get a sheet, in order.
+// Any other transfer type gets a sheet of its own after these.
+var sheetOrder = []string{"TRANSFER IN", "TRANSFER OUT"}
+
+// Reader streams club transfer rows from a sheet of an XLSX workbook
+type Reader struct {
+	*csvutil.ClubTransferReader
+
+	file *excelize.File
+	rows *excelize.Rows
+}
+
+// OpenClubTransferXLSX opens a sheet of an XLSX workbook for reading club
+// transfer rows one at a time. The sheet is chosen by name or by its
+// 1-based position; the first sheet is read when sheet is empty. The header
+// row is matched like that of a CSV input file. The reader must be closed.
+func OpenClubTransferXLSX(fileName, sheet string, config csvutil.Config) (*Reader, error) {
+	f, err := excelize.OpenFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	name, err := resolveSheet(f, sheet)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	rows, err := f.Rows(name)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to read sheet %s: %w", name, err)
+	}
+
+	r := &Reader{file: f, rows: rows}
+	line := 0
+	r.ClubTransferReader, err = csvutil.NewClubTransferReader(func() ([]string, int, error) {
+		if !rows.Next() {
+			if err := rows.Error(); err != nil {
+				return nil, 0, fmt.Errorf("failed to read sheet %s: %w", name, err)
+			}
+			return nil, 0, io.EOF
+		}
+		line++
+		record, err := rows.Columns()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read sheet %s: %w", name, err)
+		}
+		return record, line, nil
+	}, config)
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close releases the workbook
+func (r *Reader) Close() error {
+	if err := r.rows.Close(); err != nil {
+		_ = r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// ReadClubTransferXLSX reads all club transfer rows from a sheet of an XLSX
+// workbook, selected as for OpenClubTransferXLSX
+func ReadClubTransferXLSX(fileName, sheet string, config csvutil.Config) ([]model.ClubTransferRow, error) {
+	r, err := OpenClubTransferXLSX(fileName, sheet, config)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	return r.Collect()
+}
+
+// resolveSheet returns the name of the sheet selected by name or 1-based position
+func resolveSheet(f *excelize.File, sheet string) (string, error) {
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return "", fmt.Errorf("workbook has no sheets")
+	}
+	if sheet == "" {
+		return sheets[0], nil
+	}
+	if slices.Contains(sheets, sheet) {
+		return sheet, nil
+	}
+	if index, err := strconv.Atoi(sheet); err == nil {
+		if index < 1 || index > len(sheets) {
+			return "", fmt.Errorf("sheet %d out of range, workbook has %d sheets", index, len(sheets))
+		}
+		return sheets[index-1], nil
+	}
+	return "", fmt.Errorf("sheet %q not found, workbook has sheets: %s", sheet, strings.Join(sheets, ", "))
+}
+
+// GenerateXLSXContent generates an XLSX workbook in memory with one sheet
+// per transfer type and the given output columns, or the default ones when
+// columns is empty. Every sheet has a bold header row frozen at the top and
+// date-typed date cells.
+func GenerateXLSXContent(data []model.ClubTransferData, columns schema.Columns) ([]byte, error) {
+	if len(columns) == 0 {
+		columns = schema.DefaultOutput()
+	}
+
+	f := excelize.NewFile()
+	defer func() {
+		_ = f.Close()
+	}()
+
+	groups := make(map[string][]model.ClubTransferData)
+	sheets := append([]string(nil), sheetOrder...)
+	for _, transfer := range data {
+		if !slices.Contains(sheets, transfer.TransferType) {
+			sheets = append(sheets, transfer.TransferType)
+		}
+		groups[transfer.TransferType] = append(groups[transfer.TransferType], transfer)
+	}
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#D9E1F2"}, Pattern: 1},
+		Border: []excelize.Border{
+			{Type: "bottom", Color: "#000000", Style: 1},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create header style: %w", err)
+	}
+	dateFormat := "yyyy-mm-dd"
+	dateStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create date style: %w", err)
+	}
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := f.SetSheetName(f.GetSheetName(0), sheet); err != nil {
+				return nil, fmt.Errorf("failed to name sheet %s: %w", sheet, err)
+			}
+		} else if _, err := f.NewSheet(sheet); err != nil {
+			return nil, fmt.Errorf("failed to create sheet %s: %w", sheet, err)
+		}
+		if err := writeSheet(f, sheet, groups[sheet], columns, headerStyle, dateStyle); err != nil {
+			return nil, fmt.Errorf("failed to write sheet %s: %w", sheet, err)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeSheet writes the header and one row per transfer to the sheet
+func writeSheet(
+	f *excelize.File,
+	sheet string,
+	data []model.ClubTransferData,
+	columns schema.Columns,
+	headerStyle, dateStyle int,
+) error {
+	row := make([]interface{}, len(columns))
+	for i, header := range columns.Names() {
+		row[i] = header
+	}
+	if err := f.SetSheetRow(sheet, "A1", &row); err != nil {
+		return err
+	}
+	lastColumn, err := excelize.ColumnNumberToName(len(columns))
+	if err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(sheet, "A1", lastColumn+"1", headerStyle); err != nil {
+		return err
+	}
+
+	for i, transfer := range data {
+		cell := fmt.Sprintf("A%d", i+2)
+		row := make([]interface{}, len(columns))
+		for j, column := range columns {
+			row[j] = column.DataValue(transfer)
+		}
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			return err
+		}
+	}
+	for i, column := range columns {
+		if !column.IsDate() || len(data) == 0 {
+			continue
+		}
+		name, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sheet, name+"2", fmt.Sprintf("%s%d", name, len(data)+1), dateStyle); err != nil {
+			return err
+		}
+	}
+
+	if err := f.SetColWidth(sheet, "A", lastColumn, 16); err != nil {
+		return err
+	}
+	return f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+}
@@ -0,0 +1,252 @@
+package xlsxutil
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"coral.daniel-guo.com/internal/csvutil"
+	"coral.daniel-guo.com/internal/model"
+	"coral.daniel-guo.com/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestGenerateXLSXContent(t *testing.T) {
+	transferDate := time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)
+	data := []model.ClubTransferData{
+		{
+			MemberID:       "12345",
+			FobNumber:      "FOB001",
+			FirstName:      "John",
+			LastName:       "Doe",
+			MembershipType: "Premium",
+			HomeClub:       "CLUB A",
+			TargetClub:     "CLUB B",
+			TransferType:   "TRANSFER OUT",
+			TransferDate:   transferDate,
+		},
+		{
+			MemberID:       "67890",
+			FobNumber:      "FOB002",
+			FirstName:      "Jane",
+			LastName:       "Smith",
+			MembershipType: "Standard",
+			HomeClub:       "CLUB C",
+			TargetClub:     "CLUB A",
+			TransferType:   "TRANSFER IN",
+			TransferDate:   transferDate,
+		},
+	}
+
+	content, err := GenerateXLSXContent(data, nil)
+	require.NoError(t, err)
+
+	f, err := excelize.OpenReader(bytes.NewReader(content))
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+
+	assert.Equal(t, []string{"TRANSFER IN", "TRANSFER OUT"}, f.GetSheetList())
+
+	rows, err := f.GetRows("TRANSFER IN")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, schema.DefaultOutput().Names(), rows[0])
+	assert.Equal(t, []string{
+		"67890", "FOB002", "Jane", "Smith", "Standard", "CLUB C", "CLUB A", "TRANSFER IN", "2025-05-15",
+	}, rows[1])
+
+	rows, err = f.GetRows("TRANSFER OUT")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "12345", rows[1][0])
+
+	// Transfer dates are stored as date serials, not text
+	cellType, err := f.GetCellType("TRANSFER IN", "I2")
+	require.NoError(t, err)
+	assert.NotEqual(t, excelize.CellTypeSharedString, cellType)
+	assert.NotEqual(t, excelize.CellTypeInlineString, cellType)
+	raw, err := f.GetCellValue("TRANSFER IN", "I2", excelize.Options{RawCellValue: true})
+	require.NoError(t, err)
+	assert.Equal(t, "45792", raw)
+
+	panes, err := f.GetPanes("TRANSFER IN")
+	require.NoError(t, err)
+	assert.True(t, panes.Freeze)
+	assert.Equal(t, 1, panes.YSplit)
+
+	style, err := f.GetCellStyle("TRANSFER IN", "A1")
+	require.NoError(t, err)
+	headerStyle, err := f.GetStyle(style)
+	require.NoError(t, err)
+	assert.True(t, headerStyle.Font.Bold)
+}
+
+func TestGenerateXLSXContentEmpty(t *testing.T) {
+	content, err := GenerateXLSXContent(nil, nil)
+	require.NoError(t, err)
+
+	f, err := excelize.OpenReader(bytes.NewReader(content))
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+
+	// Both sheets exist with just the header row
+	assert.Equal(t, []string{"TRANSFER IN", "TRANSFER OUT"}, f.GetSheetList())
+	rows, err := f.GetRows("TRANSFER OUT")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{schema.DefaultOutput().Names()}, rows)
+}
+
+func TestGenerateXLSXContentCustomColumns(t *testing.T) {
+	data := []model.ClubTransferData{{
+		MemberID:     "12345",
+		TransferType: "TRANSFER IN",
+		TransferDate: time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC),
+	}}
+
+	content, err := GenerateXLSXContent(data, schema.Columns{
+		{Name: "Date", Field: "TransferDate"},
+		{Name: "Member", Field: "MemberID"},
+	})
+	require.NoError(t, err)
+
+	f, err := excelize.OpenReader(bytes.NewReader(content))
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+
+	rows, err := f.GetRows("TRANSFER IN")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"Date", "Member"}, {"2025-05-15", "12345"}}, rows)
+	raw, err := f.GetCellValue("TRANSFER IN", "A2", excelize.Options{RawCellValue: true})
+	require.NoError(t, err)
+	assert.Equal(t, "45792", raw)
+}
+
+// writeWorkbook saves a workbook with the given sheets of rows to a temporary file
+func writeWorkbook(t *testing.T, sheets map[string][][]interface{}, order ...string) string {
+	t.Helper()
+	f := excelize.NewFile()
+	defer func() {
+		_ = f.Close()
+	}()
+
+	for i, sheet := range order {
+		if i == 0 {
+			require.NoError(t, f.SetSheetName(f.GetSheetName(0), sheet))
+		} else {
+			_, err := f.NewSheet(sheet)
+			require.NoError(t, err)
+		}
+		for r, row := range sheets[sheet] {
+			cell, err := excelize.CoordinatesToCellName(1, r+1)
+			require.NoError(t, err)
+			require.NoError(t, f.SetSheetRow(sheet, cell, &row))
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "transfers.xlsx")
+	require.NoError(t, f.SaveAs(path))
+	return path
+}
+
+func TestReadClubTransferXLSX(t *testing.T) {
+	header := []interface{}{
+		"Member Id", "Fob Number", "First Name", "Last Name", "Membership Type", "Home Club", "Target Club",
+	}
+	path := writeWorkbook(t, map[string][][]interface{}{
+		"PIF": {
+			header,
+			{12345, "FOB001", "John", "Doe", "Premium", "club a", "CLUB B"},
+			// Trailing empty cells are not stored
+			{67890, "FOB002", "Jane", "Smith", "Standard", "CLUB C"},
+		},
+		"DD": {
+			header,
+			{11111, "FOB003", "Bob", "Johnson", "Basic", "CLUB D", "CLUB E"},
+		},
+		"Notes": {
+			{"Member Id", "Comment"},
+		},
+	}, "PIF", "DD", "Notes")
+
+	t.Run("should read the first sheet by default", func(t *testing.T) {
+		rows, err := ReadClubTransferXLSX(path, "", csvutil.DefaultConfig())
+
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		assert.Equal(t, model.ClubTransferRow{
+			MemberID:       "12345",
+			FobNumber:      "FOB001",
+			FirstName:      "John",
+			LastName:       "Doe",
+			MembershipType: "Premium",
+			HomeClub:       "CLUB A",
+			TargetClub:     "CLUB B",
+			Line:           2,
+		}, rows[0])
+		assert.Equal(t, "", rows[1].TargetClub)
+	})
+
+	t.Run("should select a sheet by name or position", func(t *testing.T) {
+		byName, err := ReadClubTransferXLSX(path, "DD", csvutil.DefaultConfig())
+		require.NoError(t, err)
+		byIndex, err := ReadClubTransferXLSX(path, "2", csvutil.DefaultConfig())
+		require.NoError(t, err)
+
+		require.Len(t, byName, 1)
+		assert.Equal(t, "11111", byName[0].MemberID)
+		assert.Equal(t, byName, byIndex)
+	})
+
+	t.Run("should report unknown sheets", func(t *testing.T) {
+		_, err := ReadClubTransferXLSX(path, "Summary", csvutil.DefaultConfig())
+		assert.EqualError(t, err, `sheet "Summary" not found, workbook has sheets: PIF, DD, Notes`)
+
+		_, err = ReadClubTransferXLSX(path, "4", csvutil.DefaultConfig())
+		assert.EqualError(t, err, "sheet 4 out of range, workbook has 3 sheets")
+	})
+
+	t.Run("should validate required columns", func(t *testing.T) {
+		_, err := ReadClubTransferXLSX(path, "Notes", csvutil.DefaultConfig())
+		assert.EqualError(t, err, "column Fob Number not found")
+	})
+
+	t.Run("should fail for a missing file", func(t *testing.T) {
+		_, err := ReadClubTransferXLSX(filepath.Join(t.TempDir(), "missing.xlsx"), "", csvutil.DefaultConfig())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to open file")
+	})
+}
+
+func TestOpenClubTransferXLSX(t *testing.T) {
+	path := writeWorkbook(t, map[string][][]interface{}{
+		"Transfers": {
+			{"Member Id", "Fob Number", "First Name", "Last Name", "Membership Type", "Home Club", "Target Club"},
+			{12345, "FOB001", "John", "Doe", "Premium", "CLUB A", "CLUB B"},
+			{},
+			{67890, "FOB002", "Jane", "Smith", "Standard", "CLUB C", "CLUB A"},
+		},
+	}, "Transfers")
+
+	reader, err := OpenClubTransferXLSX(path, "", csvutil.DefaultConfig())
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, reader.Close())
+	}()
+
+	var lines []int
+	for row, err := range reader.All() {
+		require.NoError(t, err)
+		lines = append(lines, row.Line)
+	}
+	// Lines are the spreadsheet row numbers, counting the empty row
+	assert.Equal(t, []int{2, 4}, lines)
+}